@@ -0,0 +1,9 @@
+//go:build !windows
+// +build !windows
+
+package cgLogger
+
+// ansiSupported reports if the colors can be written to w, the terminals render them out of Windows.
+func ansiSupported(w Writer) bool {
+	return true
+}
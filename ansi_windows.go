@@ -0,0 +1,56 @@
+//go:build windows
+// +build windows
+
+package cgLogger
+
+import (
+	"log"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// enableVirtualTerminalProcessing is the console mode rendering the ANSI escape codes (Windows 10 1511 and later).
+const enableVirtualTerminalProcessing = 0x0004
+
+var (
+	setConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+	// consoles are the results of enableVirtualTerminal by handle, the mode is only changed once.
+	consoles sync.Map
+)
+
+// ansiSupported reports if the colors can be written to w. The virtual terminal processing of the console
+// is enabled the first time a colorful line is written to it, it is false when the console can't render
+// the colors and the lines are then written without them.
+func ansiSupported(w Writer) bool {
+	l, ok := w.(*log.Logger)
+	if !ok {
+		return true
+	}
+	f, ok := l.Writer().(*os.File)
+	if !ok {
+		return true
+	}
+
+	h := syscall.Handle(f.Fd())
+	if enabled, found := consoles.Load(h); found {
+		return enabled.(bool)
+	}
+	enabled, _ := consoles.LoadOrStore(h, enableVirtualTerminal(h))
+	return enabled.(bool)
+}
+
+// enableVirtualTerminal reports if the handle renders the escape codes. It is true when it isn't a console
+// (a file or the pipe of a terminal like mintty), the codes are written as they are.
+func enableVirtualTerminal(h syscall.Handle) bool {
+	var mode uint32
+	if err := syscall.GetConsoleMode(h, &mode); err != nil {
+		return true
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	ok, _, _ := setConsoleMode.Call(uintptr(h), uintptr(mode|enableVirtualTerminalProcessing))
+	return ok != 0
+}
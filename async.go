@@ -0,0 +1,146 @@
+package cgLogger
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// asyncQueue writes the lines from a background goroutine, so the queries don't wait for the Writer.
+type asyncQueue struct {
+	queue   chan Entry
+	write   func(e Entry)
+	drop    bool
+	dropped int64
+
+	mu       sync.RWMutex
+	closed   bool
+	done     chan struct{}
+	flushReq chan chan struct{}
+}
+
+func newAsyncQueue(size int, drop bool, write func(e Entry)) *asyncQueue {
+	if size <= 0 {
+		size = 1024
+	}
+	q := &asyncQueue{
+		queue:    make(chan Entry, size),
+		write:    write,
+		drop:     drop,
+		done:     make(chan struct{}),
+		flushReq: make(chan chan struct{}),
+	}
+	go q.run()
+	return q
+}
+
+// add queues e, when the queue is full it waits for room or drops e.
+// The lines added after close are dropped.
+func (q *asyncQueue) add(e Entry) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		atomic.AddInt64(&q.dropped, 1)
+		return
+	}
+	if !q.drop {
+		q.queue <- e
+		return
+	}
+	select {
+	case q.queue <- e:
+	default:
+		atomic.AddInt64(&q.dropped, 1)
+	}
+}
+
+func (q *asyncQueue) run() {
+	for {
+		select {
+		case e, ok := <-q.queue:
+			if !ok {
+				close(q.done)
+				return
+			}
+			q.write(e)
+		case ack := <-q.flushReq:
+			for n := len(q.queue); n > 0; n-- {
+				q.write(<-q.queue)
+			}
+			close(ack)
+		}
+	}
+}
+
+// flush waits for the lines queued before the call.
+func (q *asyncQueue) flush() {
+	ack := make(chan struct{})
+	select {
+	case q.flushReq <- ack:
+		<-ack
+	case <-q.done:
+	}
+}
+
+// close writes the queued lines and stops the goroutine.
+func (q *asyncQueue) close() {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+		close(q.queue)
+	}
+	q.mu.Unlock()
+
+	<-q.done
+}
+
+// Async makes the lines be written from a background goroutine with a buffer of bufferSize lines
+// (1024 if <= 0). When the buffer is full the line waits for room, or is dropped if dropWhenFull is set,
+// see DroppedLines. Call it before LogMode and call Close (or Flush) on shutdown to write the queued lines.
+func (l *customLogger) Async(bufferSize int, dropWhenFull bool) CInterface {
+	if l.async != nil {
+		l.async.close()
+	}
+	direct := *l
+	direct.async = nil
+	l.async = newAsyncQueue(bufferSize, dropWhenFull, direct.write)
+	return l
+}
+
+// DroppedLines returns how many lines were dropped because the async buffer was full.
+func (l *customLogger) DroppedLines() int64 {
+	if l.async == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&l.async.dropped)
+}
+
+// Flush waits for the queued lines to be written, then flushes the Writer if it has a Flush method.
+func (l *customLogger) Flush() {
+	if l.async != nil {
+		l.async.flush()
+	}
+	if f, ok := l.Writer.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+}
+
+// Close waits for the queued trigger calls and lines, stopping the worker and async goroutines,
+// closes the sinks created from a FileConfig, then the Writer if it is an io.Closer. The logger must not be used after it.
+func (l *customLogger) Close() error {
+	if l.pool != nil {
+		l.pool.close()
+	}
+	// the lines still queued are written before the sinks are closed.
+	if l.async != nil {
+		l.async.close()
+	}
+	for _, c := range l.closers {
+		_ = c.Close()
+	}
+	if c, ok := l.Writer.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
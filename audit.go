@@ -0,0 +1,69 @@
+package cgLogger
+
+import (
+	"context"
+)
+
+// auditLog writes the data-mutating statements to a dedicated writer, see Audit.
+type auditLog struct {
+	writer Writer
+	actor  func(ctx context.Context) string
+}
+
+// Audit writes every INSERT, UPDATE and DELETE, failed ones included, to w as a JSON line with the time, caller,
+// affected rows, statement and the actor returned by actor (when not nil), whatever the level, sampling
+// or rate limit of the operational lines. A nil w stops the audit:
+//
+//	audit, _ := os.OpenFile("audit.log", os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+//	logger.Audit(log.New(audit, "", 0), func(ctx context.Context) string {
+//		return ctx.Value(userKey{}).(string)
+//	})
+//
+// The statements are the redacted ones, w may be an EntryWriter to get the entries.
+func (l *customLogger) Audit(w Writer, actor func(ctx context.Context) string) CInterface {
+	if w == nil {
+		l.audit = nil
+		return l
+	}
+	l.audit = &auditLog{writer: w, actor: actor}
+	return l
+}
+
+// record writes the entry of g when it is a mutation.
+func (a *auditLog) record(g GormInfos, fields []Field) {
+	switch g.Operation {
+	case OperationInsert, OperationUpdate, OperationDelete:
+	default:
+		return
+	}
+
+	e := Entry{
+		Context:  g.Context,
+		Time:     g.End,
+		Level:    LevelInfo,
+		Location: g.Location,
+		Message:  "audit",
+		Trace:    true,
+		Duration: g.Elapsed,
+		Rows:     g.AffectedRows,
+		SQL:      g.Sql,
+		Err:      g.Err,
+	}
+	if a.actor != nil && g.Context != nil {
+		e.Fields = append(e.Fields, Field{Key: "actor", Value: a.actor(g.Context)})
+	}
+	e.Fields = append(e.Fields, Field{Key: "operation", Value: g.Operation})
+	if len(g.Tables) > 0 {
+		e.Fields = append(e.Fields, Field{Key: "tables", Value: g.Tables})
+	}
+	e.Fields = append(e.Fields, fields...)
+
+	if w, ok := a.writer.(EntryWriter); ok {
+		w.WriteEntry(e)
+		return
+	}
+	b := getBuffer()
+	defer putBuffer(b)
+	encodeJSON(b, e, Config{})
+	a.writer.Printf("%s", b.Bytes())
+}
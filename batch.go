@@ -0,0 +1,134 @@
+package cgLogger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// batcher queues events in memory and flushes them in batches from a single goroutine.
+// When the queue is full the new events are dropped so the queries are never blocked by a sink.
+type batcher struct {
+	queue    chan interface{}
+	size     int
+	interval time.Duration
+	flush    func(batch []interface{})
+	dropped  int64
+
+	mu       sync.RWMutex
+	closed   bool
+	done     chan struct{}
+	flushReq chan chan struct{}
+}
+
+func newBatcher(queue, size int, interval time.Duration, flush func(batch []interface{})) *batcher {
+	if queue <= 0 {
+		queue = 10000
+	}
+	if size <= 0 {
+		size = 500
+	}
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	b := &batcher{
+		queue:    make(chan interface{}, queue),
+		size:     size,
+		interval: interval,
+		flush:    flush,
+		done:     make(chan struct{}),
+		flushReq: make(chan chan struct{}),
+	}
+	go b.run()
+	return b
+}
+
+// add queues v, it returns false when the queue is full (or closed) and v is dropped.
+func (b *batcher) add(v interface{}) bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		atomic.AddInt64(&b.dropped, 1)
+		return false
+	}
+
+	select {
+	case b.queue <- v:
+		return true
+	default:
+		atomic.AddInt64(&b.dropped, 1)
+		return false
+	}
+}
+
+// dropCount returns how many events were dropped because the queue was full.
+func (b *batcher) dropCount() int64 {
+	return atomic.LoadInt64(&b.dropped)
+}
+
+func (b *batcher) run() {
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	batch := make([]interface{}, 0, b.size)
+	send := func() {
+		if len(batch) > 0 {
+			b.flush(batch)
+			batch = make([]interface{}, 0, b.size)
+		}
+	}
+
+	for {
+		select {
+		case v, ok := <-b.queue:
+			if !ok {
+				send()
+				close(b.done)
+				return
+			}
+			batch = append(batch, v)
+			if len(batch) >= b.size {
+				send()
+			}
+		case <-ticker.C:
+			send()
+		case ack := <-b.flushReq:
+			for n := len(b.queue); n > 0; n-- {
+				v, ok := <-b.queue
+				if !ok {
+					break
+				}
+				batch = append(batch, v)
+				if len(batch) >= b.size {
+					send()
+				}
+			}
+			send()
+			close(ack)
+		}
+	}
+}
+
+// sync sends what is queued and waits for it, it does nothing after close.
+func (b *batcher) sync() {
+	ack := make(chan struct{})
+	select {
+	case b.flushReq <- ack:
+		<-ack
+	case <-b.done:
+	}
+}
+
+// close flushes the queued events and stops the goroutine, the events added after it are dropped.
+func (b *batcher) close() {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	<-b.done
+}
@@ -0,0 +1,168 @@
+package cgLogger
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// bufferPool holds the buffers the lines are formatted in, so a line doesn't allocate its own.
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// maxPooledBuffer is the capacity from which a buffer isn't kept, a huge statement shouldn't stay in memory.
+const maxPooledBuffer = 64 << 10
+
+func getBuffer() *bytes.Buffer {
+	b := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+	return b
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() <= maxPooledBuffer {
+		bufferPool.Put(b)
+	}
+}
+
+// writeLine sends the formatted line to the writer. The *log.Logger and RotatingFile writers get it without
+// the fmt formatting of Printf.
+func (l customLogger) writeLine(b *bytes.Buffer) {
+	switch w := l.Writer.(type) {
+	case *log.Logger:
+		_ = w.Output(3, b.String())
+	case *RotatingFile:
+		if b.Len() == 0 || b.Bytes()[b.Len()-1] != '\n' {
+			b.WriteByte('\n')
+		}
+		_, _ = w.Write(b.Bytes())
+	default:
+		l.Printf("%s", b.Bytes())
+	}
+}
+
+// appendf writes format to b replacing each %s and %v by the next arg and %% by %.
+// It is enough for the format strings of textStyle, without the boxing of the arguments of fmt.
+func appendf(b *bytes.Buffer, format string, args ...string) {
+	for {
+		i := indexVerb(format)
+		if i < 0 {
+			b.WriteString(format)
+			return
+		}
+		b.WriteString(format[:i])
+		switch format[i+1] {
+		case '%':
+			b.WriteByte('%')
+		default:
+			if len(args) > 0 {
+				b.WriteString(args[0])
+				args = args[1:]
+			}
+		}
+		format = format[i+2:]
+	}
+}
+
+// indexVerb returns the index of the next %s, %v or %%, -1 without them.
+func indexVerb(format string) int {
+	for i := 0; i+1 < len(format); i++ {
+		if format[i] == '%' {
+			switch format[i+1] {
+			case 's', 'v', '%':
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// appendJSONString writes s quoted as encoding/json does without the HTML escaping.
+func appendJSONString(b *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	b.WriteByte('"')
+	start := 0
+	for i := 0; i < len(s); {
+		c := s[i]
+		if c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' {
+				i++
+				continue
+			}
+			b.WriteString(s[start:i])
+			switch c {
+			case '"', '\\':
+				b.WriteByte('\\')
+				b.WriteByte(c)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				b.WriteString(`\u00`)
+				b.WriteByte(hex[c>>4])
+				b.WriteByte(hex[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			b.WriteString(s[start:i])
+			b.WriteString("\ufffd")
+			i += size
+			start = i
+			continue
+		}
+		// U+2028 and U+2029 break the JavaScript parsers.
+		if r == '\u2028' || r == '\u2029' {
+			b.WriteString(s[start:i])
+			b.WriteString(`\u202`)
+			b.WriteByte(hex[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	b.WriteString(s[start:])
+	b.WriteByte('"')
+}
+
+// appendJSONValue writes the common values without encoding/json, it returns false for the other ones.
+func appendJSONValue(b *bytes.Buffer, value interface{}) bool {
+	var scratch [32]byte
+	switch v := value.(type) {
+	case string:
+		appendJSONString(b, v)
+	case error:
+		appendJSONString(b, v.Error())
+	case bool:
+		b.Write(strconv.AppendBool(scratch[:0], v))
+	case int:
+		b.Write(strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int64:
+		b.Write(strconv.AppendInt(scratch[:0], v, 10))
+	case float64:
+		return appendJSONFloat(b, v)
+	default:
+		return false
+	}
+	return true
+}
+
+// appendJSONFloat writes v as encoding/json does in the range it writes without exponent, it returns false out of it.
+func appendJSONFloat(b *bytes.Buffer, v float64) bool {
+	// encoding/json uses the exponent format out of this range, NaN and Inf fail.
+	if abs := math.Abs(v); abs != 0 && !(abs >= 1e-6 && abs < 1e21) {
+		return false
+	}
+	var scratch [32]byte
+	b.Write(strconv.AppendFloat(scratch[:0], v, 'f', -1, 64))
+	return true
+}
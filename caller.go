@@ -0,0 +1,78 @@
+package cgLogger
+
+import (
+	"context"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// packagePrefix is the prefix of the functions of this package, ex: "cgLogger."
+var packagePrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	name := runtime.FuncForPC(pc).Name()
+	slash := strings.LastIndex(name, "/")
+	return name[:slash+1+strings.Index(name[slash+1:], ".")+1]
+}()
+
+// callerOutside returns the file:line of the first frame that isn't in this package
+// nor in a function starting with one of the prefixes, ex: "database/sql.".
+// It is used by the adapters where gorm's utils.FileWithLineNum would point to the library code.
+func callerOutside(prefixes ...string) string {
+	return callerSkipping(0, prefixes)
+}
+
+// callerSkipping is callerOutside skipping skip more frames once out of this package and the prefixes.
+func callerSkipping(skip int, prefixes []string) string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		frame, more := frames.Next()
+		if !skipFrame(frame, prefixes) {
+			if skip == 0 {
+				return frame.File + ":" + strconv.Itoa(frame.Line)
+			}
+			skip--
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// gormCallers are the prefixes of the functions of gorm, skipped as utils.FileWithLineNum does.
+var gormCallers = []string{"gorm.io/gorm.", "gorm.io/gorm/"}
+
+// skipCaller returns location, the caller found by utils.FileWithLineNum, or the caller resolved with
+// Config.CallerSkip and Config.CallerSkipPrefixes when they are set.
+func (l customLogger) skipCaller(location string) string {
+	config := l.config()
+	if config.CallerSkip <= 0 && len(config.CallerSkipPrefixes) == 0 {
+		return location
+	}
+	return callerSkipping(config.CallerSkip, append(append([]string(nil), gormCallers...), config.CallerSkipPrefixes...))
+}
+
+func skipFrame(frame runtime.Frame, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(frame.Function, p) {
+			return true
+		}
+	}
+	if strings.HasSuffix(frame.File, "_test.go") {
+		return false
+	}
+	return strings.HasPrefix(frame.Function, packagePrefix)
+}
+
+// traceAt traces with the given location when l is a customLogger.
+func traceAt(l CInterface, ctx context.Context, location string, begin time.Time, fc func() (string, int64), err error) {
+	if cl, ok := l.(*customLogger); ok {
+		cl.trace(ctx, location, begin, fc, err)
+		return
+	}
+	l.Trace(ctx, begin, fc, err)
+}
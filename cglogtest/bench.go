@@ -0,0 +1,34 @@
+package cglogtest
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"cgLogger"
+)
+
+// Benchmark traces q b.N times with a logger built from config, writing to ioutil.Discard through a *log.Logger
+// as the default writer of gorm, and reports the allocations. setup, when not nil, adds the triggers:
+//
+//	func BenchmarkInfo(b *testing.B) {
+//		cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Info}, nil, cglogtest.Query{SQL: "SELECT 1", Rows: 1})
+//	}
+func Benchmark(b *testing.B, config cgLogger.Config, setup func(l cgLogger.CInterface), q Query) {
+	l := cgLogger.New(log.New(ioutil.Discard, "\r\n", log.LstdFlags), config)
+	if setup != nil {
+		setup(l)
+	}
+	ctx := context.Background()
+	fc := func() (string, int64) {
+		return q.SQL, q.Rows
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		l.Trace(ctx, time.Now().Add(-q.Duration), fc, q.Err)
+	}
+}
@@ -0,0 +1,210 @@
+// Package cglogtest has helpers to regression-test the output of a cgLogger configuration
+// (formats, colors, templates) against golden files.
+//
+//	func TestOutput(t *testing.T) {
+//		got := cglogtest.Render(config, func(l cgLogger.CInterface) {
+//			cglogtest.Trace(l, cglogtest.Query{SQL: "SELECT 1", Rows: 1})
+//		})
+//		cglogtest.AssertGolden(t, "testdata/select.golden", got)
+//	}
+//
+// Run the tests with CGLOGGER_UPDATE=1 to write the golden files:
+//
+//	CGLOGGER_UPDATE=1 go test ./...
+package cglogtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cgLogger"
+)
+
+// UpdateEnv is the environment variable writing the golden files instead of comparing them, when set
+// to a true value of strconv.ParseBool. An environment variable doesn't clash with the flags of the tests.
+const UpdateEnv = "CGLOGGER_UPDATE"
+
+func update() bool {
+	on, _ := strconv.ParseBool(os.Getenv(UpdateEnv))
+	return on
+}
+
+// Query is a statement to be traced by Trace.
+type Query struct {
+	SQL      string
+	Rows     int64
+	Duration time.Duration
+	Err      error
+}
+
+// Trace sends q to l as gorm would after executing it.
+// The location of the trace is this file as it is the first caller out of gorm.
+func Trace(l cgLogger.CInterface, q Query) {
+	l.Trace(context.Background(), time.Now().Add(-q.Duration), func() (string, int64) {
+		return q.SQL, q.Rows
+	}, q.Err)
+}
+
+// buffer is the Writer used by Render.
+type buffer struct {
+	bytes.Buffer
+}
+
+func (b *buffer) Printf(format string, data ...interface{}) {
+	fmt.Fprintf(&b.Buffer, format, data...)
+	b.WriteByte('\n')
+}
+
+// Render builds a logger with config, runs f with it and returns the normalized output.
+func Render(config cgLogger.Config, f func(l cgLogger.CInterface)) string {
+	var b buffer
+	f(cgLogger.New(&b, config))
+	return Normalize(b.String(), config.TimeFormat)
+}
+
+var (
+	location = regexp.MustCompile(`(?:[A-Za-z]:)?[/\\](?:[^\s"'=\x1b]*[/\\])?([^/\\\s"'=\x1b]+\.go):\d+`)
+	// duration matches the durations of every DurationFormat and of time.Duration.String: 1.234ms, 850.000µs,
+	// 2.500s, 1234000ns, 850µs. The integer milliseconds and seconds (the thresholds, ex: 200ms) are kept.
+	duration  = regexp.MustCompile(`\b(?:\d+(?:\.\d+)?(ns|µs|us)|\d+\.\d+(ms|s))\b`)
+	jsonDur   = regexp.MustCompile(`"duration_(ms|ns)":[0-9.e+-]+`)
+	logfmtDur = regexp.MustCompile(`dur_(ms|ns)=[0-9.e+-]+`)
+	gcpFile   = regexp.MustCompile(`"file":"[^"]*[/\\]([^/\\"]+\.go)"`)
+	gcpLine   = regexp.MustCompile(`"line":"\d+"`)
+	timestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+	// dateTime matches the other common layouts: 2006-01-02 15:04:05.000, 2006/01/02 15:04:05 (log.LstdFlags).
+	dateTime = regexp.MustCompile(`\d{4}([-/])\d{2}[-/]\d{2} \d{2}:\d{2}:\d{2}(\.\d+)?`)
+)
+
+// Normalize removes what changes between runs: the directory and line of the callers, the durations and
+// the timestamps. The timestamps are recognized in RFC 3339, in the common date time layouts and in the
+// layouts given (the Config.TimeFormat), they are replaced by the reference time of their layout.
+func Normalize(s string, layouts ...string) string {
+	s = location.ReplaceAllString(s, "$1:0")
+	s = gcpFile.ReplaceAllString(s, `"file":"$1"`)
+	s = gcpLine.ReplaceAllString(s, `"line":"0"`)
+	for _, layout := range layouts {
+		if p := timePattern(layout); p != nil {
+			s = p.ReplaceAllLiteralString(s, reference.Format(layout))
+		}
+	}
+	s = timestamp.ReplaceAllString(s, "2006-01-02T15:04:05Z")
+	s = dateTime.ReplaceAllStringFunc(s, dateTimeReference)
+	s = jsonDur.ReplaceAllString(s, `"duration_$1":0`)
+	s = logfmtDur.ReplaceAllString(s, "dur_$1=0")
+	return duration.ReplaceAllStringFunc(s, zeroDuration)
+}
+
+// dateTimeReference writes the reference time in place of a dateTime match, keeping its separators
+// and the length of its fraction of second.
+func dateTimeReference(m string) string {
+	sub := dateTime.FindStringSubmatch(m)
+	s := "2006" + sub[1] + "01" + sub[1] + "02 15:04:05"
+	if sub[2] != "" {
+		s += "." + strings.Repeat("0", len(sub[2])-1)
+	}
+	return s
+}
+
+// zeroDuration writes 0 in place of the number of a duration match, keeping its unit: 1.234ms and 1.2345ms
+// (time.Duration.String trims the zeros) are 0.000ms.
+func zeroDuration(m string) string {
+	unit := strings.TrimLeft(m, "0123456789.")
+	if strings.IndexByte(m, '.') >= 0 {
+		return "0.000" + unit
+	}
+	return "0" + unit
+}
+
+// reference is the time written instead of the timestamps.
+var reference = time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// timePattern returns the regular expression matching the times written with layout: the numbers of any
+// width, the fractions of seconds if any, the names of the months, days and zones, and the zone offsets.
+// It is nil for the layouts made only of numbers, they can't be told apart from the other numbers.
+func timePattern(layout string) *regexp.Regexp {
+	if layout == "" {
+		return nil
+	}
+	sample := time.Date(2006, 1, 2, 15, 4, 5, 123456789, time.FixedZone("CET", 3600)).Format(layout)
+	if strings.Trim(sample, "0123456789") == "" {
+		return nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(sample); {
+		c := sample[i]
+		j := i + 1
+		switch {
+		case c == '.' && j < len(sample) && isDigit(sample[j]):
+			for j < len(sample) && isDigit(sample[j]) {
+				j++
+			}
+			b.WriteString(`(?:\.\d+)?`)
+		case c == '+' && j < len(sample) && isDigit(sample[j]):
+			for j < len(sample) && (isDigit(sample[j]) || sample[j] == ':') {
+				j++
+			}
+			b.WriteString(`(?:Z|[+-][\d:]+)`)
+		case isDigit(c):
+			for j < len(sample) && isDigit(sample[j]) {
+				j++
+			}
+			b.WriteString(`\d+`)
+		case isLetter(c):
+			for j < len(sample) && isLetter(sample[j]) {
+				j++
+			}
+			b.WriteString(`[A-Za-z]+`)
+		case c == ' ':
+			for j < len(sample) && sample[j] == ' ' {
+				j++
+			}
+			b.WriteString(` +`)
+		default:
+			b.WriteString(regexp.QuoteMeta(sample[i:j]))
+		}
+		i = j
+	}
+	return regexp.MustCompile(b.String())
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// AssertGolden compares got with the golden file at path, with CGLOGGER_UPDATE=1 the file is written instead.
+func AssertGolden(t testing.TB, path string, got string) {
+	t.Helper()
+
+	if update() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("cglogtest: %v", err)
+		}
+		if err := ioutil.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("cglogtest: %v", err)
+		}
+		return
+	}
+
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cglogtest: %v (run with %s=1 to create it)", err, UpdateEnv)
+	}
+	if string(want) != got {
+		t.Errorf("cglogtest: output differs from %s (run with %s=1 to accept it)\n--- want\n%s\n--- got\n%s", path, UpdateEnv, want, got)
+	}
+}
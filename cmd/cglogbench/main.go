@@ -0,0 +1,58 @@
+// Command cglogbench measures the cost of a Trace for the common configurations:
+//
+//	go run ./cmd/cglogbench
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"cgLogger"
+	"cgLogger/cglogtest"
+
+	"gorm.io/gorm/logger"
+)
+
+type scenario struct {
+	name   string
+	config cgLogger.Config
+	setup  func(l cgLogger.CInterface)
+	query  cglogtest.Query
+}
+
+var query = cglogtest.Query{SQL: "SELECT * FROM `users` WHERE `users`.`id` = 42 AND `users`.`deleted_at` IS NULL LIMIT 1", Rows: 1}
+
+// triggers adds the triggers of the "/triggers" scenarios, they are executed on every query.
+func triggers(l cgLogger.CInterface) {
+	l.AlwaysTrigger(func(g cgLogger.GormInfos) {}).
+		SlowTrigger(func(g cgLogger.GormInfos) {}, time.Second).
+		ErrorTrigger(func(g cgLogger.GormInfos) {})
+}
+
+var scenarios = []scenario{
+	{name: "silent", config: cgLogger.Config{LogLevel: logger.Silent, SlowThreshold: time.Second}, query: query},
+	{name: "silent/triggers", config: cgLogger.Config{LogLevel: logger.Silent, SlowThreshold: time.Second}, setup: triggers, query: query},
+	{name: "warn", config: cgLogger.Config{LogLevel: logger.Warn, SlowThreshold: time.Second}, query: query},
+	{name: "warn/triggers", config: cgLogger.Config{LogLevel: logger.Warn, SlowThreshold: time.Second}, setup: triggers, query: query},
+	{name: "info/text", config: cgLogger.Config{LogLevel: logger.Info}, query: query},
+	{name: "info/text/triggers", config: cgLogger.Config{LogLevel: logger.Info}, setup: triggers, query: query},
+	{name: "info/text/colorful", config: cgLogger.Config{LogLevel: logger.Info, Colorful: true}, query: query},
+	{name: "info/json", config: cgLogger.Config{LogLevel: logger.Info, Format: cgLogger.JSONFormat}, query: query},
+	{name: "info/logfmt", config: cgLogger.Config{LogLevel: logger.Info, Format: cgLogger.LogfmtFormat}, query: query},
+}
+
+func main() {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "scenario\tns/op\tB/op\tallocs/op")
+	for _, s := range scenarios {
+		s := s
+		r := testing.Benchmark(func(b *testing.B) {
+			cglogtest.Benchmark(b, s.config, s.setup, s.query)
+		})
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\n", s.name, r.NsPerOp(), r.AllocedBytesPerOp(), r.AllocsPerOp())
+	}
+	w.Flush()
+}
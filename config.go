@@ -0,0 +1,618 @@
+package cgLogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// FileConfig is the configuration read by LoadConfigFile and ParseConfig. The levels, formats and durations
+// are strings ("info", "json", "500ms") so any decoder can be used, ex: yaml.Unmarshal.
+//
+//	{
+//	  "level": "warn",
+//	  "slow_threshold": "200ms",
+//	  "color": false,
+//	  "format": "json",
+//	  "triggers": {"slow_threshold": "1s"},
+//	  "sinks": {
+//	    "slack": {"webhook_url": "https://hooks.slack.com/...", "on": "slow", "max_per_minute": 5},
+//	    "statsd": {"address": "127.0.0.1:8125", "dogstatsd": true, "service": "orders"}
+//	  }
+//	}
+type FileConfig struct {
+	Level         string `json:"level" yaml:"level"`
+	SlowThreshold string `json:"slow_threshold" yaml:"slow_threshold"`
+	// OperationThresholds override the slow_threshold by operation: select, insert, update, delete, ddl or other.
+	OperationThresholds map[string]string `json:"operation_thresholds" yaml:"operation_thresholds"`
+	// TableThresholds override the slow_threshold by table.
+	TableThresholds map[string]string `json:"table_thresholds" yaml:"table_thresholds"`
+	// CriticalThreshold is the second tier of slow queries, see Config.CriticalThreshold.
+	CriticalThreshold string `json:"critical_threshold" yaml:"critical_threshold"`
+	Color             *bool  `json:"color" yaml:"color"`
+	Format            string `json:"format" yaml:"format"`
+	IgnoreNotFound    bool   `json:"ignore_not_found" yaml:"ignore_not_found"`
+	// ParameterizedQueries logs the statements without their values.
+	ParameterizedQueries bool `json:"parameterized_queries" yaml:"parameterized_queries"`
+	// SampleInfo writes 1 of every sample_info successful Info traces.
+	SampleInfo int `json:"sample_info" yaml:"sample_info"`
+	// PrettySQL breaks the statements in several indented lines.
+	PrettySQL bool `json:"pretty_sql" yaml:"pretty_sql"`
+	// TimeFormat is the Go layout of the time of the lines, ex: "2006-01-02 15:04:05.000".
+	TimeFormat string `json:"time_format" yaml:"time_format"`
+	UTC        bool   `json:"utc" yaml:"utc"`
+	// CallerSkip and CallerSkipPrefixes change how the caller is resolved, see Config.CallerSkip.
+	CallerSkip         int      `json:"caller_skip" yaml:"caller_skip"`
+	CallerSkipPrefixes []string `json:"caller_skip_prefixes" yaml:"caller_skip_prefixes"`
+	// DisableCaller doesn't resolve the caller of the lines.
+	DisableCaller bool `json:"disable_caller" yaml:"disable_caller"`
+	// DurationFormat is ms, auto or ns, see DurationFormat.
+	DurationFormat string `json:"duration_format" yaml:"duration_format"`
+	// Templates are the text/template layouts of the text format, see Templates.
+	Templates *FileTemplates `json:"templates" yaml:"templates"`
+	// Theme is the built-in theme of the colors: default, light or monochrome-bold.
+	Theme string `json:"theme" yaml:"theme"`
+
+	Redact   *FileRedact  `json:"redact" yaml:"redact"`
+	Triggers FileTriggers `json:"triggers" yaml:"triggers"`
+	Sinks    FileSinks    `json:"sinks" yaml:"sinks"`
+}
+
+// FileRedact are the redaction rules, see Redact.
+type FileRedact struct {
+	// Defaults applies DefaultRedactRules before the Rules.
+	Defaults bool `json:"defaults" yaml:"defaults"`
+	Rules    []struct {
+		Name        string `json:"name" yaml:"name"`
+		Pattern     string `json:"pattern" yaml:"pattern"`
+		Replacement string `json:"replacement" yaml:"replacement"`
+	} `json:"rules" yaml:"rules"`
+}
+
+// FileTemplates are the layouts of the text format, the empty ones keep the default layout.
+type FileTemplates struct {
+	Info  string `json:"info" yaml:"info"`
+	Warn  string `json:"warn" yaml:"warn"`
+	Error string `json:"error" yaml:"error"`
+	Trace string `json:"trace" yaml:"trace"`
+}
+
+// parse parses the layouts into Templates.
+func (f *FileTemplates) parse() (Templates, error) {
+	var t Templates
+	for _, tmpl := range []struct {
+		name   string
+		text   string
+		target **template.Template
+	}{
+		{"info", f.Info, &t.Info},
+		{"warn", f.Warn, &t.Warn},
+		{"error", f.Error, &t.Error},
+		{"trace", f.Trace, &t.Trace},
+	} {
+		if tmpl.text == "" {
+			continue
+		}
+		parsed, err := template.New(tmpl.name).Parse(tmpl.text)
+		if err != nil {
+			return Templates{}, &FieldError{"templates." + tmpl.name, err}
+		}
+		*tmpl.target = parsed
+	}
+	return t, nil
+}
+
+// FileTriggers are the settings of the triggers.
+type FileTriggers struct {
+	// SlowThreshold of the sinks with "on": "slow", defaults to the slow_threshold of the logger.
+	SlowThreshold    string `json:"slow_threshold" yaml:"slow_threshold"`
+	Timeout          string `json:"timeout" yaml:"timeout"`
+	ConsiderNotFound bool   `json:"consider_not_found" yaml:"consider_not_found"`
+}
+
+// FileSinks are the sinks to create, the ones without their required field are not created.
+type FileSinks struct {
+	Elasticsearch *FileElasticsearch `json:"elasticsearch" yaml:"elasticsearch"`
+	StatsD        *FileStatsD        `json:"statsd" yaml:"statsd"`
+	Sentry        *FileSentry        `json:"sentry" yaml:"sentry"`
+	Slack         *FileSlack         `json:"slack" yaml:"slack"`
+	Webhook       *FileWebhook       `json:"webhook" yaml:"webhook"`
+}
+
+// FileElasticsearch configures an ElasticsearchSink.
+type FileElasticsearch struct {
+	URL         string `json:"url" yaml:"url"`
+	IndexPrefix string `json:"index_prefix" yaml:"index_prefix"`
+	Username    string `json:"username" yaml:"username"`
+	Password    string `json:"password" yaml:"password"`
+	APIKey      string `json:"api_key" yaml:"api_key"`
+	// On is when the sink is triggered: always (default), slow or error.
+	On string `json:"on" yaml:"on"`
+}
+
+// FileStatsD configures a StatsDSink, it is always triggered.
+type FileStatsD struct {
+	Address   string            `json:"address" yaml:"address"`
+	Prefix    string            `json:"prefix" yaml:"prefix"`
+	DogStatsD bool              `json:"dogstatsd" yaml:"dogstatsd"`
+	Service   string            `json:"service" yaml:"service"`
+	Tags      map[string]string `json:"tags" yaml:"tags"`
+}
+
+// FileSentry configures a SentrySink, it is triggered by the errors.
+type FileSentry struct {
+	DSN          string `json:"dsn" yaml:"dsn"`
+	Environment  string `json:"environment" yaml:"environment"`
+	Release      string `json:"release" yaml:"release"`
+	RedactSQL    bool   `json:"redact_sql" yaml:"redact_sql"`
+	MaxPerMinute int    `json:"max_per_minute" yaml:"max_per_minute"`
+}
+
+// FileSlack configures a SlackSink.
+type FileSlack struct {
+	WebhookURL   string `json:"webhook_url" yaml:"webhook_url"`
+	Template     string `json:"template" yaml:"template"`
+	Channel      string `json:"channel" yaml:"channel"`
+	MaxPerMinute int    `json:"max_per_minute" yaml:"max_per_minute"`
+	// On is when the sink is triggered: slow, error or slow_and_error (default).
+	On string `json:"on" yaml:"on"`
+}
+
+// FileWebhook configures a WebhookSink.
+type FileWebhook struct {
+	URL     string            `json:"url" yaml:"url"`
+	Headers map[string]string `json:"headers" yaml:"headers"`
+	Timeout string            `json:"timeout" yaml:"timeout"`
+	Retries int               `json:"retries" yaml:"retries"`
+	// On is when the sink is triggered: always, slow, error or slow_and_error (default).
+	On string `json:"on" yaml:"on"`
+}
+
+// FieldError is a validation error of a FileConfig, Field is its path with the keys of the file.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("cgLogger: config: %s: %v", e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+var errRequired = fmt.Errorf("required")
+
+// LoadConfigFile reads and validates a JSON file, the unknown fields are refused.
+// For other formats read the file and use ParseConfig with the decoder.
+func LoadConfigFile(path string) (*FileConfig, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if ext := strings.ToLower(filepath.Ext(path)); ext != ".json" {
+		return nil, fmt.Errorf("cgLogger: config: %s files must be read with ParseConfig and their decoder", ext)
+	}
+	return ParseConfig(data, unmarshalStrictJSON)
+}
+
+// ParseConfig decodes data with unmarshal and validates it:
+//
+//	config, err := cgLogger.ParseConfig(data, yaml.Unmarshal)
+func ParseConfig(data []byte, unmarshal func(data []byte, v interface{}) error) (*FileConfig, error) {
+	var c FileConfig
+	if err := unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("cgLogger: config: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func unmarshalStrictJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// Validate checks the values, the error is a *FieldError naming the first invalid field.
+func (c *FileConfig) Validate() error {
+	_, err := c.Options()
+	return err
+}
+
+// Options returns the options of the configuration, the sinks are closed by the Close of the logger.
+func (c *FileConfig) Options() ([]Option, error) {
+	var opts []Option
+
+	if c.Level != "" {
+		level, err := ParseLevel(c.Level)
+		if err != nil {
+			return nil, &FieldError{"level", err}
+		}
+		opts = append(opts, WithLogLevel(level))
+	}
+	slow := defaultConfig().SlowThreshold
+	if c.SlowThreshold != "" {
+		d, err := parseDuration(c.SlowThreshold)
+		if err != nil {
+			return nil, &FieldError{"slow_threshold", err}
+		}
+		slow = d
+		opts = append(opts, WithSlowThreshold(d))
+	}
+	// the keys are sorted so the first invalid field is always the same.
+	for _, op := range sortedKeys(c.OperationThresholds) {
+		s := c.OperationThresholds[op]
+		field := "operation_thresholds." + op
+		operation := strings.ToUpper(op)
+		switch operation {
+		case OperationSelect, OperationInsert, OperationUpdate, OperationDelete, OperationDDL, OperationOther:
+		default:
+			return nil, &FieldError{field, fmt.Errorf("unknown operation %q", op)}
+		}
+		d, err := parseDuration(s)
+		if err != nil {
+			return nil, &FieldError{field, err}
+		}
+		opts = append(opts, WithOperationThreshold(operation, d))
+	}
+	for _, table := range sortedKeys(c.TableThresholds) {
+		d, err := parseDuration(c.TableThresholds[table])
+		if err != nil {
+			return nil, &FieldError{"table_thresholds." + table, err}
+		}
+		opts = append(opts, WithTableThreshold(table, d))
+	}
+	if c.CriticalThreshold != "" {
+		d, err := parseDuration(c.CriticalThreshold)
+		if err != nil {
+			return nil, &FieldError{"critical_threshold", err}
+		}
+		opts = append(opts, WithCriticalThreshold(d))
+	}
+	if c.Color != nil {
+		opts = append(opts, WithColor(*c.Color))
+	}
+	if c.Format != "" {
+		f, err := parseFormat(c.Format)
+		if err != nil {
+			return nil, &FieldError{"format", err}
+		}
+		opts = append(opts, WithFormat(f))
+	}
+	opts = append(opts, WithIgnoreRecordNotFoundError(c.IgnoreNotFound), WithParameterizedQueries(c.ParameterizedQueries),
+		WithPrettySQL(c.PrettySQL), WithSampleInfo(c.SampleInfo), WithUTC(c.UTC))
+	if c.TimeFormat != "" {
+		opts = append(opts, WithTimeFormat(c.TimeFormat))
+	}
+	if c.CallerSkip < 0 {
+		return nil, &FieldError{"caller_skip", fmt.Errorf("must not be negative")}
+	}
+	opts = append(opts, WithCallerSkip(c.CallerSkip), WithCallerSkipPrefixes(c.CallerSkipPrefixes...),
+		WithDisableCaller(c.DisableCaller))
+	if c.DurationFormat != "" {
+		f, err := parseDurationFormat(c.DurationFormat)
+		if err != nil {
+			return nil, &FieldError{"duration_format", err}
+		}
+		opts = append(opts, WithDurationFormat(f))
+	}
+	if c.Templates != nil {
+		t, err := c.Templates.parse()
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithTemplates(t))
+	}
+	if c.Theme != "" {
+		t, err := parseTheme(c.Theme)
+		if err != nil {
+			return nil, &FieldError{"theme", err}
+		}
+		if t != nil {
+			opts = append(opts, WithTheme(*t))
+		}
+	}
+
+	if c.Redact != nil {
+		var rules []RedactRule
+		if c.Redact.Defaults {
+			rules = append(rules, DefaultRedactRules...)
+		}
+		for i, r := range c.Redact.Rules {
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, &FieldError{fmt.Sprintf("redact.rules[%d].pattern", i), err}
+			}
+			replacement := r.Replacement
+			if replacement == "" {
+				replacement = "***"
+			}
+			rules = append(rules, RedactRule{Name: r.Name, Pattern: re, Replacement: replacement})
+		}
+		if len(rules) > 0 {
+			opts = append(opts, withSetup(func(l CInterface) { l.Redact(rules...) }))
+		}
+	}
+
+	if c.Triggers.SlowThreshold != "" {
+		d, err := parseDuration(c.Triggers.SlowThreshold)
+		if err != nil {
+			return nil, &FieldError{"triggers.slow_threshold", err}
+		}
+		slow = d
+	}
+	if c.Triggers.Timeout != "" {
+		d, err := parseDuration(c.Triggers.Timeout)
+		if err != nil {
+			return nil, &FieldError{"triggers.timeout", err}
+		}
+		opts = append(opts, withSetup(func(l CInterface) { l.TriggerTimeout(d) }))
+	}
+	if c.Triggers.ConsiderNotFound {
+		opts = append(opts, withSetup(func(l CInterface) { l.ConsiderNotFound(true) }))
+	}
+
+	sinks, err := c.Sinks.options(slow)
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, sinks...), nil
+}
+
+func (s FileSinks) options(slow time.Duration) ([]Option, error) {
+	var opts []Option
+
+	if es := s.Elasticsearch; es != nil {
+		if es.URL == "" {
+			return nil, &FieldError{"sinks.elasticsearch.url", errRequired}
+		}
+		on, err := parseOn(es.On, "always", "sinks.elasticsearch.on")
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, withSink("elasticsearch", on, slow, func() (func(GormInfos), io.Closer, error) {
+			sink := NewElasticsearchSink(ElasticsearchConfig{
+				URL: es.URL, IndexPrefix: es.IndexPrefix, Username: es.Username, Password: es.Password, APIKey: es.APIKey,
+			})
+			return sink.Trigger, sink, nil
+		}))
+	}
+
+	if sd := s.StatsD; sd != nil {
+		if sd.Address == "" {
+			return nil, &FieldError{"sinks.statsd.address", errRequired}
+		}
+		opts = append(opts, withSink("statsd", "always", slow, func() (func(GormInfos), io.Closer, error) {
+			sink, err := NewStatsDSink(StatsDConfig{
+				Address: sd.Address, Prefix: sd.Prefix, DogStatsD: sd.DogStatsD, Service: sd.Service, Tags: sd.Tags,
+			})
+			if err != nil {
+				return nil, nil, &FieldError{"sinks.statsd.address", err}
+			}
+			return sink.Trigger, sink, nil
+		}))
+	}
+
+	if se := s.Sentry; se != nil {
+		if se.DSN == "" {
+			return nil, &FieldError{"sinks.sentry.dsn", errRequired}
+		}
+		config := SentryConfig{
+			DSN: se.DSN, Environment: se.Environment, Release: se.Release, RedactSQL: se.RedactSQL, MaxPerMinute: se.MaxPerMinute,
+		}
+		// the DSN is checked now, the sink is created with the logger.
+		if _, _, err := parseSentryDSN(se.DSN); err != nil {
+			return nil, &FieldError{"sinks.sentry.dsn", err}
+		}
+		opts = append(opts, withSink("sentry", "error", slow, func() (func(GormInfos), io.Closer, error) {
+			sink, err := NewSentrySink(config)
+			if err != nil {
+				return nil, nil, err
+			}
+			return sink.Trigger, sink, nil
+		}))
+	}
+
+	if sl := s.Slack; sl != nil {
+		if sl.WebhookURL == "" {
+			return nil, &FieldError{"sinks.slack.webhook_url", errRequired}
+		}
+		on, err := parseOn(sl.On, "slow_and_error", "sinks.slack.on")
+		if err != nil {
+			return nil, err
+		}
+		config := SlackConfig{WebhookURL: sl.WebhookURL, Template: sl.Template, Channel: sl.Channel, MaxPerMinute: sl.MaxPerMinute}
+		if _, err := template.New("slack").Parse(sl.Template); err != nil {
+			return nil, &FieldError{"sinks.slack.template", err}
+		}
+		opts = append(opts, withSink("slack", on, slow, func() (func(GormInfos), io.Closer, error) {
+			sink, err := NewSlackSink(config)
+			if err != nil {
+				return nil, nil, err
+			}
+			return sink.Trigger, sink, nil
+		}))
+	}
+
+	if wh := s.Webhook; wh != nil {
+		if wh.URL == "" {
+			return nil, &FieldError{"sinks.webhook.url", errRequired}
+		}
+		on, err := parseOn(wh.On, "slow_and_error", "sinks.webhook.on")
+		if err != nil {
+			return nil, err
+		}
+		config := WebhookConfig{URL: wh.URL, Headers: wh.Headers, Retries: wh.Retries}
+		if wh.Timeout != "" {
+			if config.Timeout, err = parseDuration(wh.Timeout); err != nil {
+				return nil, &FieldError{"sinks.webhook.timeout", err}
+			}
+		}
+		opts = append(opts, withSink("webhook", on, slow, func() (func(GormInfos), io.Closer, error) {
+			sink := NewWebhookSink(config)
+			return sink.Trigger, sink, nil
+		}))
+	}
+
+	return opts, nil
+}
+
+// withSink creates the sink with the logger and adds its triggers, named "sink.<name>" or "sink.<name>.slow".
+// A sink that fails to be created is a *FieldError of sinks.<name>.
+func withSink(name, on string, slow time.Duration, create func() (func(GormInfos), io.Closer, error)) Option {
+	return withFallibleSetup(func(l CInterface) error {
+		f, closer, err := create()
+		if err != nil {
+			return &FieldError{"sinks." + name, err}
+		}
+		if c, ok := l.(*customLogger); ok {
+			c.closers = append(c.closers, closer)
+		}
+
+		trigger := "sink." + name
+		switch on {
+		case "always":
+			l.AddAlwaysTrigger(trigger, f)
+		case "slow":
+			l.AddSlowTrigger(trigger, f, slow)
+		case "error":
+			l.AddErrorTrigger(trigger, f)
+		default:
+			l.AddSlowTrigger(trigger+".slow", f, slow)
+			l.AddErrorTrigger(trigger, f)
+		}
+		return nil
+	})
+}
+
+func parseOn(on, def, field string) (string, error) {
+	switch on {
+	case "":
+		return def, nil
+	case "always", "slow", "error", "slow_and_error":
+		return on, nil
+	}
+	return "", &FieldError{field, fmt.Errorf("unknown value %q, expected always, slow, error or slow_and_error", on)}
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// parseDuration reads the durations of the config files and of the environment variables, they can't be negative.
+func parseDuration(s string) (time.Duration, error) {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative duration %s", s)
+	}
+	return d, nil
+}
+
+// Config returns the configuration of the logger in the file, without the triggers and sinks.
+func (c *FileConfig) Config() (Config, error) {
+	opts, err := c.Options()
+	if err != nil {
+		return Config{}, err
+	}
+	o := options{config: defaultConfig()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o.config, nil
+}
+
+// WatchConfigFile checks the JSON file every interval (5s by default) and reloads the configuration
+// of the logger when the file changes, see Reload. The triggers and sinks of the file are not reloaded.
+// The invalid files are reported to onError (when not nil) and the current configuration is kept.
+// The returned function stops the watching.
+func (l *customLogger) WatchConfigFile(path string, interval time.Duration, onError func(err error)) (stop func()) {
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var last time.Time
+		if info, err := os.Stat(path); err == nil {
+			last = info.ModTime()
+		}
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+
+			info, err := os.Stat(path)
+			if err != nil || !info.ModTime().After(last) {
+				continue
+			}
+			last = info.ModTime()
+
+			config, err := loadFileConfig(path)
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				continue
+			}
+			l.Reload(config)
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func loadFileConfig(path string) (Config, error) {
+	c, err := LoadConfigFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	return c.Config()
+}
+
+// NewFromConfigFile builds a logger as NewWithOptions with the options of the JSON file after opts.
+// It fails when one of the sinks can't be created, the ones created are closed.
+func NewFromConfigFile(writer Writer, path string, opts ...Option) (CInterface, error) {
+	c, err := LoadConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+	fileOpts, err := c.Options()
+	if err != nil {
+		return nil, err
+	}
+	l, errs := newWithOptions(writer, append(opts, fileOpts...))
+	if len(errs) > 0 {
+		_ = l.Close()
+		return nil, errs[0]
+	}
+	return l, nil
+}
@@ -0,0 +1,72 @@
+package cgLogger
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	lg "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// PoolStats is a sample of the connection pool taken by WatchPool.
+type PoolStats struct {
+	sql.DBStats
+	// Waits and WaitTime are the waits for a connection since the previous sample.
+	Waits    int64
+	WaitTime time.Duration
+}
+
+// WatchPool logs the stats of the connection pool of db every interval and calls f (when not nil) with them,
+// until the returned function is called. A sample with waits for a connection, the pool is exhausted,
+// is logged at Warn so it shows up next to the slow queries it causes, the others at Info.
+// Nothing is watched when interval is <= 0, the returned function does nothing.
+//
+//	sqlDB, _ := db.DB()
+//	stop := logger.WatchPool(sqlDB, 30*time.Second, nil)
+//	defer stop()
+func (l *customLogger) WatchPool(db *sql.DB, interval time.Duration, f func(s PoolStats)) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	location := utils.FileWithLineNum()
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		previous := db.Stats()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				current := db.Stats()
+				s := PoolStats{
+					DBStats:  current,
+					Waits:    current.WaitCount - previous.WaitCount,
+					WaitTime: current.WaitDuration - previous.WaitDuration,
+				}
+				previous = current
+				l.logPool(location, s)
+				if f != nil {
+					f(s)
+				}
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func (l customLogger) logPool(location string, s PoolStats) {
+	data := []interface{}{
+		"open", s.OpenConnections, "max_open", s.MaxOpenConnections, "in_use", s.InUse, "idle", s.Idle,
+		"waits", s.Waits, "wait_time", s.WaitTime, "wait_count", s.WaitCount, "wait_duration", s.WaitDuration,
+	}
+	if s.Waits > 0 {
+		l.printf(context.Background(), lg.Warn, LevelWarn, location, "POOL EXHAUSTED: %d waits for a connection", append([]interface{}{s.Waits}, data...))
+		return
+	}
+	l.printf(context.Background(), lg.Info, LevelInfo, location, "pool stats", data)
+}
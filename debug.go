@@ -0,0 +1,128 @@
+package cgLogger
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+
+	lg "gorm.io/gorm/logger"
+)
+
+// debugState is what DebugHandler renders.
+type debugState struct {
+	Config      debugConfig        `json:"config"`
+	Stats       Stats              `json:"stats"`
+	TopSlow     []SlowQuery        `json:"top_slow"`
+	Percentiles []QueryPercentiles `json:"percentiles"`
+	Recent      []GormInfos        `json:"recent"`
+}
+
+type debugConfig struct {
+	Level             string `json:"level"`
+	SlowThreshold     string `json:"slow_threshold"`
+	CriticalThreshold string `json:"critical_threshold"`
+	Format            string `json:"format"`
+	Colorful          bool   `json:"color"`
+	IgnoreNotFound    bool   `json:"ignore_not_found"`
+	Parameterized     bool   `json:"parameterized_queries"`
+	SampleInfo        int    `json:"sample_info"`
+}
+
+// levelName is the name of a gorm level read by ParseLevel.
+func levelName(level lg.LogLevel) string {
+	switch level {
+	case lg.Silent:
+		return "silent"
+	case lg.Error:
+		return "error"
+	case lg.Warn:
+		return "warn"
+	case lg.Info:
+		return "info"
+	}
+	return "unknown"
+}
+
+func (l *customLogger) debugState() debugState {
+	config := l.config()
+	return debugState{
+		Config: debugConfig{
+			Level:             levelName(l.level()),
+			SlowThreshold:     config.SlowThreshold.String(),
+			CriticalThreshold: config.CriticalThreshold.String(),
+			Format:            config.Format.String(),
+			Colorful:          config.Colorful,
+			IgnoreNotFound:    config.IgnoreRecordNotFoundError,
+			Parameterized:     config.ParameterizedQueries,
+			SampleInfo:        config.SampleInfo,
+		},
+		Stats:       l.Stats(),
+		TopSlow:     l.TopSlow(),
+		Percentiles: l.Percentiles(),
+		Recent:      l.Recent(),
+	}
+}
+
+var debugPage = template.Must(template.New("debug").Parse(`<!DOCTYPE html>
+<html><head><title>cgLogger</title>
+<style>body{font-family:sans-serif}table{border-collapse:collapse}td,th{border:1px solid #ccc;padding:2px 6px;text-align:left}code{white-space:pre-wrap}</style>
+</head><body>
+<h1>cgLogger</h1>
+<h2>Config</h2>
+<table>
+<tr><th>level</th><td>{{.Config.Level}}</td></tr>
+<tr><th>slow threshold</th><td>{{.Config.SlowThreshold}}</td></tr>
+<tr><th>critical threshold</th><td>{{.Config.CriticalThreshold}}</td></tr>
+<tr><th>format</th><td>{{.Config.Format}}</td></tr>
+<tr><th>parameterized queries</th><td>{{.Config.Parameterized}}</td></tr>
+<tr><th>sample info</th><td>{{.Config.SampleInfo}}</td></tr>
+</table>
+<h2>Stats</h2>
+<table>
+<tr><th>since</th><td>{{.Stats.Since}}</td></tr>
+<tr><th>queries</th><td>{{.Stats.Queries}}</td></tr>
+<tr><th>slow queries</th><td>{{.Stats.SlowQueries}}</td></tr>
+<tr><th>errors</th><td>{{.Stats.Errors}}</td></tr>
+<tr><th>not found</th><td>{{.Stats.NotFound}}</td></tr>
+<tr><th>min / avg / max</th><td>{{.Stats.MinDuration}} / {{.Stats.AvgDuration}} / {{.Stats.MaxDuration}}</td></tr>
+</table>
+{{if .TopSlow}}<h2>Top slow</h2>
+<table><tr><th>max</th><th>avg</th><th>count</th><th>query</th><th>caller</th></tr>
+{{range .TopSlow}}<tr><td>{{.Max}}</td><td>{{.Avg}}</td><td>{{.Count}}</td><td><code>{{.SQL}}</code></td><td>{{.Location}}</td></tr>
+{{end}}</table>{{end}}
+{{if .Percentiles}}<h2>Percentiles</h2>
+<table><tr><th>p50</th><th>p95</th><th>p99</th><th>count</th><th>fingerprint</th></tr>
+{{range .Percentiles}}<tr><td>{{.P50}}</td><td>{{.P95}}</td><td>{{.P99}}</td><td>{{.Count}}</td><td><code>{{.Fingerprint}}</code></td></tr>
+{{end}}</table>{{end}}
+{{if .Recent}}<h2>Recent</h2>
+<table><tr><th>begin</th><th>duration</th><th>rows</th><th>query</th><th>error</th><th>caller</th></tr>
+{{range .Recent}}<tr><td>{{.Begin.Format "15:04:05.000"}}</td><td>{{.Elapsed}}</td><td>{{.AffectedRows}}</td><td><code>{{.Sql}}</code></td><td>{{if .Err}}{{.Err}}{{end}}</td><td>{{.Location}}</td></tr>
+{{end}}</table>{{end}}
+</body></html>
+`))
+
+// DebugHandler renders the config, Stats, TopSlow, Percentiles and Recent queries for on-call inspection,
+// as HTML for the browsers (or with ?format=html) and as JSON otherwise:
+//
+//	mux.Handle("/debug/cglogger", logger.DebugHandler())
+//
+// The recent statements are shown as logged, use ParameterizedQueries or Redact when they hold sensitive values
+// and don't expose the handler publicly.
+func (l *customLogger) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := l.debugState()
+
+		format := r.URL.Query().Get("format")
+		if format == "html" || format == "" && strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_ = debugPage.Execute(w, state)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(state)
+	})
+}
@@ -0,0 +1,233 @@
+package cgLogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ElasticsearchMapping is the default body used to create the daily indexes.
+const ElasticsearchMapping = `{
+  "mappings": {
+    "properties": {
+      "@timestamp":     {"type": "date"},
+      "schema_version": {"type": "integer"},
+      "caller":         {"type": "keyword"},
+      "duration_ms":    {"type": "double"},
+      "rows":           {"type": "long"},
+      "sql":            {"type": "text", "fields": {"keyword": {"type": "keyword", "ignore_above": 2048}}},
+      "error":          {"type": "text"}
+    }
+  }
+}`
+
+// ElasticsearchConfig configures an ElasticsearchSink, only URL is required.
+type ElasticsearchConfig struct {
+	// URL of the cluster, ex: http://localhost:9200
+	URL string
+	// IndexPrefix of the daily indexes, defaults to "cglogger": cglogger-2021.07.01
+	IndexPrefix string
+	// DateLayout of the index suffix, defaults to "2006.01.02".
+	DateLayout string
+	// Mapping is the body used to create each index before its first write, defaults to ElasticsearchMapping.
+	Mapping string
+	// Username and Password for basic auth, or APIKey.
+	Username string
+	Password string
+	APIKey   string
+
+	// BatchSize is the max documents per bulk request, defaults to 500.
+	BatchSize int
+	// FlushInterval is the max time an event waits to be sent, defaults to 5s.
+	FlushInterval time.Duration
+	// QueueSize is the max of events waiting to be sent, the new ones are dropped when full. Defaults to 10000.
+	QueueSize int
+
+	// Client defaults to a client with a 10s timeout.
+	Client *http.Client
+	// OnError receives the failed requests, by default they are ignored.
+	OnError func(error)
+}
+
+// ElasticsearchSink indexes the query events using the bulk API.
+//
+//	sink := cgLogger.NewElasticsearchSink(cgLogger.ElasticsearchConfig{URL: "http://localhost:9200"})
+//	defer sink.Close()
+//	logger.AlwaysTrigger(sink.Trigger)
+type ElasticsearchSink struct {
+	config  ElasticsearchConfig
+	batcher *batcher
+
+	mu      sync.Mutex
+	created map[string]bool
+}
+
+// esEvent is an event waiting to be indexed.
+type esEvent struct {
+	at    time.Time
+	infos GormInfos
+}
+
+// NewElasticsearchSink starts the goroutine sending the batches, Close stops it.
+func NewElasticsearchSink(config ElasticsearchConfig) *ElasticsearchSink {
+	config.URL = strings.TrimRight(config.URL, "/")
+	if config.IndexPrefix == "" {
+		config.IndexPrefix = "cglogger"
+	}
+	if config.DateLayout == "" {
+		config.DateLayout = "2006.01.02"
+	}
+	if config.Mapping == "" {
+		config.Mapping = ElasticsearchMapping
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	s := &ElasticsearchSink{config: config, created: map[string]bool{}}
+	s.batcher = newBatcher(config.QueueSize, config.BatchSize, config.FlushInterval, s.send)
+	return s
+}
+
+// Trigger queues the event, it is meant to be passed to AlwaysTrigger, SlowTrigger or ErrorTrigger.
+func (s *ElasticsearchSink) Trigger(g GormInfos) {
+	s.batcher.add(esEvent{at: time.Now(), infos: g})
+}
+
+// Dropped returns how many events were dropped because the queue was full.
+func (s *ElasticsearchSink) Dropped() int64 {
+	return s.batcher.dropCount()
+}
+
+// Flush sends the queued events and waits for them.
+func (s *ElasticsearchSink) Flush() {
+	s.batcher.sync()
+}
+
+// Close sends the queued events and stops the sink.
+func (s *ElasticsearchSink) Close() error {
+	s.batcher.close()
+	return nil
+}
+
+func (s *ElasticsearchSink) send(batch []interface{}) {
+	var (
+		body bytes.Buffer
+		docs int
+		// failed are the indexes that couldn't be created, their events are dropped.
+		failed map[string]bool
+	)
+	for _, v := range batch {
+		e := v.(esEvent)
+		index := s.config.IndexPrefix + "-" + e.at.UTC().Format(s.config.DateLayout)
+		if failed[index] {
+			continue
+		}
+		if err := s.ensureIndex(index); err != nil {
+			if failed == nil {
+				failed = map[string]bool{}
+			}
+			failed[index] = true
+			s.fail(err)
+			continue
+		}
+		docs++
+
+		doc, _ := e.infos.MarshalJSON()
+		fmt.Fprintf(&body, `{"index":{"_index":%q}}`+"\n", index)
+		fmt.Fprintf(&body, `{"@timestamp":%q,%s`+"\n", e.at.UTC().Format(time.RFC3339Nano), doc[1:])
+	}
+	if docs == 0 {
+		return
+	}
+
+	resp, err := s.do(http.MethodPost, "/_bulk", "application/x-ndjson", &body)
+	if err != nil {
+		s.fail(err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Errors bool `json:"errors"`
+	}
+	if resp.StatusCode >= 300 {
+		s.fail(responseError("bulk", resp))
+		return
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err == nil && result.Errors {
+		s.fail(fmt.Errorf("cgLogger: elasticsearch bulk request had item errors"))
+	}
+}
+
+// ensureIndex creates the index with the mapping the first time it is used.
+func (s *ElasticsearchSink) ensureIndex(index string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.created[index] {
+		return nil
+	}
+
+	resp, err := s.do(http.MethodPut, "/"+index, "application/json", strings.NewReader(s.config.Mapping))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 && !indexExists(resp) {
+		return responseError("create index "+index, resp)
+	}
+	s.created[index] = true
+	return nil
+}
+
+// indexExists reports if the failed response of an index creation is a 400 resource_already_exists_exception,
+// the other ones (ex: a rejected mapping) are errors. The body is kept for responseError.
+func indexExists(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	var result struct {
+		Error struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	return json.Unmarshal(body, &result) == nil && result.Error.Type == "resource_already_exists_exception"
+}
+
+func (s *ElasticsearchSink) do(method, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.config.URL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	switch {
+	case s.config.APIKey != "":
+		req.Header.Set("Authorization", "ApiKey "+s.config.APIKey)
+	case s.config.Username != "":
+		req.SetBasicAuth(s.config.Username, s.config.Password)
+	}
+	return s.config.Client.Do(req)
+}
+
+func (s *ElasticsearchSink) fail(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
+
+// responseError builds an error with the status and the beginning of the body of a failed response.
+func responseError(action string, resp *http.Response) error {
+	body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("cgLogger: %s: %s: %s", action, resp.Status, bytes.TrimSpace(body))
+}
@@ -0,0 +1,64 @@
+package cgLogger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	lg "gorm.io/gorm/logger"
+)
+
+// entCallers are skipped when resolving the location of an ent query.
+var entCallers = []string{"entgo.io/ent"}
+
+// Ent returns a function to be used with ent's debug driver so its statements are traced by l:
+//
+//	drv := dialect.DebugWithContext(sqlDriver, cgLogger.Ent(logger))
+//	client := ent.NewClient(ent.Driver(drv))
+//
+// ent logs before running the statement, so the duration and affected rows aren't known (rows is -1)
+// and only the AlwaysTrigger is useful. Lines that aren't statements (transactions) are logged as Info.
+func Ent(l CInterface) func(ctx context.Context, v ...interface{}) {
+	return func(ctx context.Context, v ...interface{}) {
+		if noop {
+			return
+		}
+
+		msg := fmt.Sprint(v...)
+		location := callerOutside(entCallers...)
+
+		query, ok := entQuery(msg)
+		if !ok {
+			if cl, isCustom := l.(*customLogger); isCustom {
+				cl.printf(ctx, lg.Info, LevelInfo, location, "%s", []interface{}{msg})
+				return
+			}
+			l.Info(ctx, "%s", msg)
+			return
+		}
+
+		traceAt(l, ctx, location, time.Now(), func() (string, int64) { return query, -1 }, nil)
+	}
+}
+
+// entQuery extracts the statement of the lines written by ent's debug driver:
+//
+//	driver.Query: query=SELECT ... args=[1 2]
+//	Tx(f0c3...).Exec: query=INSERT ... args=[]
+func entQuery(msg string) (string, bool) {
+	i := strings.Index(msg, "query=")
+	if i < 0 {
+		return "", false
+	}
+
+	query := msg[i+len("query="):]
+	if j := strings.LastIndex(query, " args="); j >= 0 {
+		args := query[j+len(" args="):]
+		query = query[:j]
+		if args != "[]" {
+			query += " /* args=" + args + " */"
+		}
+	}
+	return query, true
+}
@@ -0,0 +1,103 @@
+package cgLogger
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Environment variables read by NewFromEnv.
+const (
+	EnvLevel          = "CGLOGGER_LEVEL"            // silent, error, warn, info
+	EnvSlowThreshold  = "CGLOGGER_SLOW_THRESHOLD"   // a positive time.Duration, ex: 500ms
+	EnvColor          = "CGLOGGER_COLOR"            // a bool, ex: false
+	EnvFormat         = "CGLOGGER_FORMAT"           // text, json, logfmt, cloudlogging
+	EnvIgnoreNotFound = "CGLOGGER_IGNORE_NOT_FOUND" // a bool
+)
+
+// NewFromEnv builds a logger as NewWithOptions, the variables that are set take precedence over opts,
+// so the logging can be tuned per deployment:
+//
+//	CGLOGGER_LEVEL=info CGLOGGER_SLOW_THRESHOLD=500ms CGLOGGER_COLOR=false CGLOGGER_FORMAT=json ./service
+//
+// An invalid value returns an error naming the variable.
+func NewFromEnv(writer Writer, opts ...Option) (CInterface, error) {
+	env, err := envOptions()
+	if err != nil {
+		return nil, err
+	}
+	return NewWithOptions(writer, append(opts, env...)...), nil
+}
+
+// envOptions returns the options of the variables that are set.
+func envOptions() ([]Option, error) {
+	var opts []Option
+
+	if v, ok := lookupEnv(EnvLevel); ok {
+		level, err := ParseLevel(v)
+		if err != nil {
+			return nil, envError(EnvLevel, err)
+		}
+		opts = append(opts, WithLogLevel(level))
+	}
+	if v, ok := lookupEnv(EnvSlowThreshold); ok {
+		d, err := parseDuration(v)
+		if err != nil {
+			return nil, envError(EnvSlowThreshold, err)
+		}
+		opts = append(opts, WithSlowThreshold(d))
+	}
+	if v, ok := lookupEnv(EnvColor); ok {
+		on, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, envError(EnvColor, err)
+		}
+		opts = append(opts, WithColor(on))
+	}
+	if v, ok := lookupEnv(EnvFormat); ok {
+		f, err := parseFormat(v)
+		if err != nil {
+			return nil, envError(EnvFormat, err)
+		}
+		opts = append(opts, WithFormat(f))
+	}
+	if v, ok := lookupEnv(EnvIgnoreNotFound); ok {
+		ignore, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, envError(EnvIgnoreNotFound, err)
+		}
+		opts = append(opts, WithIgnoreRecordNotFoundError(ignore))
+	}
+	return opts, nil
+}
+
+// lookupEnv returns the trimmed value, the empty variables are ignored.
+func lookupEnv(key string) (string, bool) {
+	v := strings.TrimSpace(os.Getenv(key))
+	return v, v != ""
+}
+
+func envError(key string, err error) error {
+	return fmt.Errorf("cgLogger: invalid %s: %w", key, err)
+}
+
+// parseFormat reads the names returned by Format.String.
+func parseFormat(s string) (Format, error) {
+	for _, f := range []Format{TextFormat, JSONFormat, LogfmtFormat, CloudLoggingFormat} {
+		if strings.EqualFold(strings.TrimSpace(s), f.String()) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown format %q", s)
+}
+
+// parseDurationFormat reads the names returned by DurationFormat.String.
+func parseDurationFormat(s string) (DurationFormat, error) {
+	for _, f := range []DurationFormat{DurationMillis, DurationAuto, DurationNanos} {
+		if strings.EqualFold(strings.TrimSpace(s), f.String()) {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown duration format %q, expected ms, auto or ns", s)
+}
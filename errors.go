@@ -0,0 +1,189 @@
+package cgLogger
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// Errors translated by newer gorm versions (TranslateError), they are matched by message
+// as the sentinels of gorm aren't the same values as the ones declared here.
+var (
+	ErrDuplicatedKey           = errors.New("duplicated key not allowed")
+	ErrForeignKeyViolated      = errors.New("violates foreign key constraint")
+	ErrCheckConstraintViolated = errors.New("violates check constraint")
+)
+
+// mysqlErrorNumber reads the number of the go-sql-driver/mysql errors: "Error 1062: ..." or "Error 1062 (23000): ...".
+var mysqlErrorNumber = regexp.MustCompile(`^Error (\d+)`)
+
+// sqlState returns the SQLSTATE of the postgres errors (pgx and lib/pq implement SQLState).
+func sqlState(err error) string {
+	var e interface{ SQLState() string }
+	if errors.As(err, &e) {
+		return e.SQLState()
+	}
+	return ""
+}
+
+// mysqlNumber returns the error number of mysql errors, "" if err isn't one.
+func mysqlNumber(err error) string {
+	m := mysqlErrorNumber.FindStringSubmatch(err.Error())
+	if m == nil {
+		return ""
+	}
+	return m[1]
+}
+
+// isTranslated reports if err is target or has the message of the gorm error with the same name.
+func isTranslated(err, target error) bool {
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if e == target || e.Error() == target.Error() {
+			return true
+		}
+	}
+	return false
+}
+
+// isNotFound reports ErrRecordNotFound, the one of this package or gorm's.
+func isNotFound(err error) bool {
+	return errors.Is(err, ErrRecordNotFound) || isTranslated(err, ErrRecordNotFound)
+}
+
+// isDuplicateKey reports unique constraint violations.
+func isDuplicateKey(err error) bool {
+	if err == nil {
+		return false
+	}
+	if isTranslated(err, ErrDuplicatedKey) {
+		return true
+	}
+	if sqlState(err) == "23505" || mysqlNumber(err) == "1062" {
+		return true
+	}
+	return strings.Contains(err.Error(), "UNIQUE constraint failed")
+}
+
+// isConstraintViolation reports integrity violations other than unique ones: foreign key, check and not null.
+func isConstraintViolation(err error) bool {
+	if err == nil || isDuplicateKey(err) {
+		return false
+	}
+	if isTranslated(err, ErrForeignKeyViolated) || isTranslated(err, ErrCheckConstraintViolated) {
+		return true
+	}
+	if state := sqlState(err); state != "" {
+		return strings.HasPrefix(state, "23")
+	}
+	switch mysqlNumber(err) {
+	case "1048", "1216", "1217", "1451", "1452", "3819":
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "FOREIGN KEY constraint failed") ||
+		strings.Contains(msg, "CHECK constraint failed") ||
+		strings.Contains(msg, "NOT NULL constraint failed")
+}
+
+// Classes of GormInfos.ErrClass.
+const (
+	ErrClassNotFound        = "not_found"
+	ErrClassUniqueViolation = "unique_violation"
+	ErrClassForeignKey      = "foreign_key"
+	ErrClassDeadlock        = "deadlock"
+	// ErrClassTimeout are the statement, lock wait and context deadline timeouts.
+	ErrClassTimeout = "timeout"
+	// ErrClassConnection are the broken, refused and closed connections.
+	ErrClassConnection = "connection"
+	ErrClassOther      = "other"
+)
+
+// ErrorClass classifies a query error with the Postgres SQLSTATE and MySQL error numbers, falling back to
+// the messages of sqlite and the standard library. It returns "" for a nil error.
+func ErrorClass(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case isNotFound(err):
+		return ErrClassNotFound
+	case isDuplicateKey(err):
+		return ErrClassUniqueViolation
+	case isForeignKey(err):
+		return ErrClassForeignKey
+	}
+
+	switch lockKind(err) {
+	case LockDeadlock:
+		return ErrClassDeadlock
+	case LockWaitTimeout:
+		return ErrClassTimeout
+	}
+	if isTimeout(err) {
+		return ErrClassTimeout
+	}
+	if isConnectionError(err) {
+		return ErrClassConnection
+	}
+	return ErrClassOther
+}
+
+// isCanceled reports the errors of the queries whose context was canceled or timed out,
+// the drivers don't always wrap the context error so the context is checked too.
+func isCanceled(ctx context.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return ctx != nil && ctx.Err() != nil
+}
+
+func isForeignKey(err error) bool {
+	if isTranslated(err, ErrForeignKeyViolated) || sqlState(err) == "23503" {
+		return true
+	}
+	switch mysqlNumber(err) {
+	case "1216", "1217", "1451", "1452":
+		return true
+	}
+	return strings.Contains(err.Error(), "FOREIGN KEY constraint failed")
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	// statement_timeout / max_execution_time
+	if sqlState(err) == "57014" || mysqlNumber(err) == "3024" {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	switch state := sqlState(err); {
+	case strings.HasPrefix(state, "08"), state == "57P01", state == "57P02", state == "57P03", state == "53300":
+		return true
+	}
+	switch mysqlNumber(err) {
+	case "1040", "1053", "2002", "2003", "2006", "2013":
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "connection refused") || strings.Contains(msg, "connection reset") ||
+		strings.Contains(msg, "broken pipe") || strings.Contains(msg, "invalid connection")
+}
@@ -0,0 +1,123 @@
+package cgLogger
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Queryer runs the EXPLAIN statements, implemented by *sql.DB (db.DB() of gorm) and *sql.Conn.
+type Queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// ExplainConfig configures ExplainSlow.
+type ExplainConfig struct {
+	// Prefix is prepended to the statement, "EXPLAIN " by default. Never use EXPLAIN ANALYZE with data
+	// changing statements: it runs them again.
+	Prefix string
+	// Operations are the statements explained, OperationSelect by default.
+	Operations []string
+	// MaxPerMinute bounds the EXPLAIN run, 6 by default.
+	MaxPerMinute int
+	// Timeout of each EXPLAIN, 2 seconds by default.
+	Timeout time.Duration
+}
+
+type explainer struct {
+	db       Queryer
+	config   ExplainConfig
+	throttle *throttle
+}
+
+// ExplainSlow runs EXPLAIN for the slow queries with db, the plan is added to the line (plan field) and set in
+// GormInfos.Plan. It runs before the line is written, in the goroutine of the query.
+// The statement is run with its values bound, never with the values written in the SQL of the line: it needs
+// the statements captured by the Plugin, the queries are not explained without it.
+// The plans echo the values in their conditions, ParameterizedQueries, Redact and MaskColumns are applied to them
+// as to the statements (with ParameterizedQueries the numbers of the plan, the costs included, are replaced by ?).
+//
+//	db.Use(cgLogger.Plugin{})
+//	sqlDB, _ := db.DB()
+//	logger.ExplainSlow(sqlDB, cgLogger.ExplainConfig{MaxPerMinute: 2})
+func (l *customLogger) ExplainSlow(db Queryer, config ExplainConfig) CInterface {
+	if db == nil {
+		l.explain = nil
+		return l
+	}
+	if config.Prefix == "" {
+		config.Prefix = "EXPLAIN "
+	}
+	if len(config.Operations) == 0 {
+		config.Operations = []string{OperationSelect}
+	}
+	if config.MaxPerMinute <= 0 {
+		config.MaxPerMinute = 6
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 2 * time.Second
+	}
+	l.explain = &explainer{db: db, config: config, throttle: newThrottle(config.MaxPerMinute, time.Minute)}
+	return l
+}
+
+// plan returns the plan of the statement captured by the Plugin in ctx, or "" when it isn't explained.
+// The statement must be the one of the operation, a statement run without the callbacks of gorm
+// doesn't replace the previous one.
+func (e *explainer) plan(ctx context.Context, operation string) string {
+	allowed := false
+	for _, op := range e.config.Operations {
+		allowed = allowed || op == operation
+	}
+	stmt := boundStatement(ctx)
+	if !allowed || stmt == nil || stmt.sql == "" || sqlOperation(stmt.sql) != operation {
+		return ""
+	}
+	if !e.throttle.allow(time.Now()) {
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.config.Timeout)
+	defer cancel()
+
+	rows, err := e.db.QueryContext(ctx, e.config.Prefix+stmt.sql, stmt.Vars...)
+	if err != nil {
+		return fmt.Sprintf("explain failed: %v", err)
+	}
+	defer rows.Close()
+
+	plan, err := readPlan(rows)
+	if err != nil {
+		return fmt.Sprintf("explain failed: %v", err)
+	}
+	return plan
+}
+
+// readPlan joins the rows of the plan by lines and their columns by " | ": postgres returns a line per row,
+// mysql a row per table.
+func readPlan(rows *sql.Rows) (string, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	var lines []string
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", err
+		}
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = v.String
+		}
+		lines = append(lines, strings.Join(cells, " | "))
+	}
+	return strings.Join(lines, "\n"), rows.Err()
+}
@@ -0,0 +1,89 @@
+package cgLogger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// queryFilter holds the statements set by IgnoreQueries and IgnoreTables, it is never modified once set:
+// the setters store a copy in the settings.
+type queryFilter struct {
+	patterns []*regexp.Regexp
+	tables   map[string]bool
+}
+
+// IgnoreQueries drops the statements matching one of the regular expressions: they are neither logged,
+// nor counted, nor given to the triggers. The writes are still given to the Audit writer.
+// The filters are shared with the loggers derived with LogMode and can be added while gorm is using the logger.
+// It panics when a pattern is invalid, like regexp.MustCompile:
+//
+//	logger.IgnoreQueries(`^SELECT 1$`, `(?i)^SET (application_name|statement_timeout)`)
+func (l *customLogger) IgnoreQueries(patterns ...string) CInterface {
+	compiled := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		compiled[i] = regexp.MustCompile(p)
+	}
+	l.settings.change(func(s *settingsSnapshot) {
+		f := s.filter.copy()
+		f.patterns = append(f.patterns, compiled...)
+		s.filter = f
+	})
+	return l
+}
+
+// IgnoreTables drops the statements touching one of the tables (case insensitive, without schema),
+// ex: the table of the migrations. See IgnoreQueries.
+func (l *customLogger) IgnoreTables(names ...string) CInterface {
+	l.settings.change(func(s *settingsSnapshot) {
+		f := s.filter.copy()
+		for _, name := range names {
+			f.tables[strings.ToLower(name)] = true
+		}
+		s.filter = f
+	})
+	return l
+}
+
+func (f *queryFilter) copy() *queryFilter {
+	c := &queryFilter{tables: map[string]bool{}}
+	if f == nil {
+		return c
+	}
+	c.patterns = append(c.patterns, f.patterns...)
+	for name := range f.tables {
+		c.tables[name] = true
+	}
+	return c
+}
+
+// ignored reports if the statement is dropped by IgnoreQueries or IgnoreTables.
+func (f *queryFilter) ignored(sql string) bool {
+	if f == nil {
+		return false
+	}
+	for _, p := range f.patterns {
+		if p.MatchString(sql) {
+			return true
+		}
+	}
+	if len(f.tables) > 0 {
+		for _, t := range sqlTables(sql) {
+			if f.tables[strings.ToLower(t)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// healthCheck matches the liveness queries of the pools and load balancers: SELECT 1, SELECT VERSION(), /* ping */ ...
+var healthCheck = regexp.MustCompile(`(?is)^\s*(?:/\*\s*ping\s*\*/\s*)?(?:` +
+	`select\s+(?:1|'1'|true|version\(\)|@@version|now\(\)|current_timestamp)(?:\s+as\s+\w+)?(?:\s+from\s+dual)?` +
+	`|ping|do\s+1)\s*;?\s*$`)
+
+// SuppressHealthChecks doesn't write the Info lines of the liveness queries (SELECT 1, SELECT VERSION(), ping...).
+// They are still counted in Stats and given to the triggers, and their errors and slow executions are still logged.
+func (l *customLogger) SuppressHealthChecks(on bool) CInterface {
+	l.settings.change(func(s *settingsSnapshot) { s.healthChecks = on })
+	return l
+}
@@ -0,0 +1,755 @@
+package cgLogger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Format is the layout used to write the log lines.
+type Format int
+
+const (
+	// TextFormat is the default gorm layout.
+	TextFormat Format = iota
+	// JSONFormat writes one JSON object per line.
+	JSONFormat
+	// LogfmtFormat writes key=value pairs: ts=... level=warn dur_ms=203 rows=4 sql="..."
+	LogfmtFormat
+	// CloudLoggingFormat writes the JSON expected by Google Cloud Logging: severity, timestamp, message
+	// and logging.googleapis.com/sourceLocation, so the levels are mapped on Cloud Run and GKE.
+	CloudLoggingFormat
+)
+
+// String returns the name of the format.
+func (f Format) String() string {
+	switch f {
+	case TextFormat:
+		return "text"
+	case JSONFormat:
+		return "json"
+	case LogfmtFormat:
+		return "logfmt"
+	case CloudLoggingFormat:
+		return "cloudlogging"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// DurationFormat is how the duration of the traces is written.
+type DurationFormat int
+
+const (
+	// DurationMillis writes milliseconds with 3 decimals: [1.234ms] in the text format, duration_ms in the others.
+	DurationMillis DurationFormat = iota
+	// DurationAuto scales the unit to the duration: [850.000µs], [1.234ms], [2.500s], duration in the others.
+	DurationAuto
+	// DurationNanos writes the nanoseconds as an integer for the machine parsing: [1234000ns], duration_ns in the others.
+	DurationNanos
+)
+
+// String returns the name of the duration format.
+func (f DurationFormat) String() string {
+	switch f {
+	case DurationMillis:
+		return "ms"
+	case DurationAuto:
+		return "auto"
+	case DurationNanos:
+		return "ns"
+	}
+	return fmt.Sprintf("DurationFormat(%d)", int(f))
+}
+
+// text returns the duration of the text format, built on the stack so only the string is allocated.
+func (f DurationFormat) text(d time.Duration) string {
+	var scratch [32]byte
+	return string(f.appendText(scratch[:0], d))
+}
+
+// appendText appends the duration of the text format to b.
+func (f DurationFormat) appendText(b []byte, d time.Duration) []byte {
+	switch f {
+	case DurationAuto:
+		switch {
+		case d < time.Millisecond:
+			b = append(strconv.AppendFloat(b, float64(d.Nanoseconds())/1e3, 'f', 3, 64), "µs"...)
+		case d < time.Second:
+			b = append(strconv.AppendFloat(b, float64(d.Nanoseconds())/1e6, 'f', 3, 64), "ms"...)
+		default:
+			b = append(strconv.AppendFloat(b, d.Seconds(), 'f', 3, 64), 's')
+		}
+	case DurationNanos:
+		b = append(strconv.AppendInt(b, d.Nanoseconds(), 10), "ns"...)
+	default:
+		b = append(strconv.AppendFloat(b, float64(d.Nanoseconds())/1e6, 'f', 3, 64), "ms"...)
+	}
+	return b
+}
+
+// Levels of the entries.
+const (
+	LevelDebug = "debug"
+	LevelInfo  = "info"
+	LevelWarn  = "warn"
+	LevelError = "error"
+)
+
+// Field is a key value pair passed after the printf arguments of Info/Warn/Error.
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Entry is a line before being formatted, it is a message (Info, Warn...) or a trace.
+type Entry struct {
+	// Context is the one given to Info/Warn/Error/Trace, context.Background() for the ones without it.
+	Context  context.Context
+	Time     time.Time
+	Level    string
+	Location string
+	// Logger is the name of the Named logger that wrote the entry.
+	Logger  string
+	Message string
+	Fields  []Field
+
+	// Trace is true for the entries written by Trace, the fields below are only set for them.
+	Trace    bool
+	Duration time.Duration
+	// Critical is set for the slow traces over the CriticalThreshold.
+	Critical bool
+	Rows     int64
+	SQL      string
+	Err      error
+
+	// infos are the GormInfos of the trace, given to the Templates.
+	infos *GormInfos
+}
+
+// EntryWriter is implemented by the writers that take the entries before being formatted,
+// ex: adapters for structured loggers. The Format of the logger is ignored for them.
+type EntryWriter interface {
+	Writer
+	WriteEntry(e Entry)
+}
+
+// write sends the entry to the writer using the configured format.
+func (l customLogger) write(e Entry) {
+	if l.name != "" && e.Logger == "" {
+		e.Logger = l.name
+	}
+	if l.async != nil {
+		l.async.add(e)
+		return
+	}
+
+	snapshot := l.settings.load()
+	config := snapshot.config
+	if config.UTC {
+		e.Time = e.Time.UTC()
+	}
+	if t, ok := l.Writer.(*tee); ok {
+		t.writeEntryFrom(snapshot, e)
+		return
+	}
+	if w, ok := l.Writer.(EntryWriter); ok {
+		w.WriteEntry(e)
+		return
+	}
+
+	b := getBuffer()
+	defer putBuffer(b)
+	switch config.Format {
+	case JSONFormat:
+		encodeJSON(b, e, config)
+	case LogfmtFormat:
+		encodeLogfmt(b, e, config)
+	case CloudLoggingFormat:
+		encodeCloudLogging(b, e, config)
+	default:
+		l.writeText(b, e)
+	}
+	l.writeLine(b)
+}
+
+// writeText keeps the layout of the gorm logger, fields are appended as key=value.
+func (l customLogger) writeText(b *bytes.Buffer, e Entry) {
+	snapshot := l.settings.load()
+	if t := snapshot.config.Templates; t != nil && writeTemplate(b, t, &e) {
+		return
+	}
+	style, colorful := snapshot.style, snapshot.config.Colorful
+	if colorful && !ansiSupported(l.Writer) {
+		style, colorful = snapshot.plain, false
+	}
+	if e.Logger != "" {
+		e.Location = "[" + e.Logger + "] " + e.Location
+	}
+	if layout := snapshot.config.TimeFormat; layout != "" {
+		e.Location = e.Time.Format(layout) + " " + e.Location
+	}
+	if !e.Trace {
+		prefix := style.infoStr
+		switch e.Level {
+		case LevelDebug:
+			prefix = style.debugStr
+		case LevelWarn:
+			prefix = style.warnStr
+		case LevelError:
+			prefix = style.errStr
+		}
+		appendf(b, prefix, e.Location)
+		b.WriteString(e.Message)
+		appendTextFields(b, e.Fields)
+		return
+	}
+
+	// rows and duration are formatted on the stack, they fit in the buffer of the string conversions.
+	var rowsScratch, durationScratch [32]byte
+	rows := "-"
+	if e.Rows != -1 {
+		rows = string(strconv.AppendInt(rowsScratch[:0], e.Rows, 10))
+	}
+	config := snapshot.config
+	duration := string(config.DurationFormat.appendText(durationScratch[:0], e.Duration))
+	sql := e.SQL
+	if config.PrettySQL {
+		sql = "\n" + prettySQL(sql)
+	}
+	if colorful {
+		base := ""
+		switch {
+		case config.Theme != nil:
+			base = config.Theme.SQL
+		case e.Critical:
+			base = Red
+		case e.Level == LevelWarn:
+			// the statement of the slow queries is Magenta, see newTextStyle.
+			base = Magenta
+		}
+		sql = highlightSQL(sql, base, config.Theme)
+	}
+	// the fields are appended to the sql so the format strings of gorm are kept.
+	if len(e.Fields) > 0 {
+		sql += textFields(e.Fields)
+	}
+
+	switch e.Level {
+	case LevelError:
+		appendf(b, style.traceErrStr, e.Location, e.Err.Error(), duration, rows, sql)
+	case LevelWarn:
+		format := style.traceWarnStr
+		if e.Critical {
+			format = style.traceCritStr
+		}
+		appendf(b, format, e.Location, e.Message, duration, rows, sql)
+	default:
+		appendf(b, style.traceStr, e.Location, duration, rows, sql)
+	}
+}
+
+// sqlKeywords are highlighted by highlightSQL.
+var sqlKeywords = map[string]bool{
+	"select": true, "from": true, "where": true, "and": true, "or": true, "not": true, "in": true, "is": true,
+	"null": true, "as": true, "on": true, "join": true, "left": true, "right": true, "inner": true, "outer": true,
+	"full": true, "cross": true, "group": true, "order": true, "by": true, "having": true, "limit": true,
+	"offset": true, "insert": true, "into": true, "values": true, "update": true, "set": true, "delete": true,
+	"returning": true, "distinct": true, "union": true, "all": true, "exists": true, "between": true, "like": true,
+	"ilike": true, "case": true, "when": true, "then": true, "else": true, "end": true, "asc": true, "desc": true,
+	"with": true, "conflict": true, "do": true, "nothing": true, "for": true, "create": true, "alter": true,
+	"drop": true, "table": true, "index": true, "true": true, "false": true, "count": true,
+}
+
+// highlightSQL colors the keywords (blue), the quoted identifiers (cyan) and the literals (green) of the statement,
+// or in the colors of the theme. base is the color of the rest of the statement, restored after each token.
+func highlightSQL(sql, base string, theme *Theme) string {
+	keyword, identifier, literal := theme.highlight()
+	var b strings.Builder
+	b.Grow(len(sql) * 2)
+
+	last := 0
+	eachToken(sql, func(t sqlToken) {
+		text := sql[t.start:t.end]
+		color := ""
+		switch {
+		case t.kind == 'l':
+			color = literal
+		case t.kind == 'i' && isSQLKeyword(text):
+			color = keyword
+		case t.kind == 'i' && (text[0] == '"' || text[0] == '`'):
+			color = identifier
+		}
+		if color == "" {
+			return
+		}
+		b.WriteString(sql[last:t.start])
+		b.WriteString(color)
+		b.WriteString(text)
+		b.WriteString(Reset)
+		b.WriteString(base)
+		last = t.end
+	})
+	b.WriteString(sql[last:])
+	return b.String()
+}
+
+// isSQLKeyword looks word up in sqlKeywords, lower casing it on the stack.
+func isSQLKeyword(word string) bool {
+	var lower [16]byte
+	if len(word) > len(lower) {
+		return false
+	}
+	for i := 0; i < len(word); i++ {
+		c := word[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	return sqlKeywords[string(lower[:len(word)])]
+}
+
+func textFields(fields []Field) string {
+	var b bytes.Buffer
+	appendTextFields(&b, fields)
+	return b.String()
+}
+
+// appendTextFields writes the fields as " key=value".
+func appendTextFields(b *bytes.Buffer, fields []Field) {
+	for _, f := range fields {
+		b.WriteByte(' ')
+		b.WriteString(f.Key)
+		b.WriteByte('=')
+		switch v := f.Value.(type) {
+		case string:
+			b.WriteString(v)
+		case int64:
+			var scratch [20]byte
+			b.Write(strconv.AppendInt(scratch[:0], v, 10))
+		default:
+			fmt.Fprint(b, v)
+		}
+	}
+}
+
+// encodeJSON writes the Entry as a JSON object keeping the keys in a stable order.
+func encodeJSON(b *bytes.Buffer, e Entry, c Config) {
+	b.WriteByte('{')
+	writeJSONField(b, SchemaVersionKey, SchemaVersion, true)
+	writeJSONTime(b, "time", e.Time, c.timeLayout())
+	writeJSONString(b, "level", e.Level, false)
+	if e.Location != "" {
+		writeJSONString(b, "caller", e.Location, false)
+	}
+	if e.Logger != "" {
+		writeJSONString(b, "logger", e.Logger, false)
+	}
+	if e.Message != "" {
+		writeJSONString(b, "msg", e.Message, false)
+	}
+	if e.Trace {
+		writeJSONTrace(b, e, c.DurationFormat)
+	}
+	if e.Err != nil {
+		writeJSONString(b, "error", e.Err.Error(), false)
+	}
+	for _, f := range e.Fields {
+		writeJSONField(b, f.Key, f.Value, false)
+	}
+	b.WriteByte('}')
+}
+
+// encodeLogfmt writes the Entry as logfmt, the keys are the ones of encodeJSON but the time (ts) and duration (dur_ms).
+func encodeLogfmt(b *bytes.Buffer, e Entry, c Config) {
+	var scratch [64]byte
+	writeLogfmtBytes(b, SchemaVersionKey, strconv.AppendInt(scratch[:0], SchemaVersion, 10))
+	writeLogfmtBytes(b, "ts", e.Time.AppendFormat(scratch[:0], c.timeLayout()))
+	writeLogfmtString(b, "level", e.Level)
+	if e.Location != "" {
+		writeLogfmtString(b, "caller", e.Location)
+	}
+	if e.Logger != "" {
+		writeLogfmtString(b, "logger", e.Logger)
+	}
+	if e.Message != "" {
+		writeLogfmtString(b, "msg", e.Message)
+	}
+	if e.Trace {
+		switch c.DurationFormat {
+		case DurationAuto:
+			writeLogfmtBytes(b, "dur", c.DurationFormat.appendText(scratch[:0], e.Duration))
+		case DurationNanos:
+			writeLogfmtBytes(b, "dur_ns", strconv.AppendInt(scratch[:0], e.Duration.Nanoseconds(), 10))
+		default:
+			writeLogfmtBytes(b, "dur_ms", strconv.AppendFloat(scratch[:0], float64(e.Duration.Nanoseconds())/1e6, 'f', 3, 64))
+		}
+		writeLogfmtBytes(b, "rows", strconv.AppendInt(scratch[:0], e.Rows, 10))
+		writeLogfmtString(b, "sql", e.SQL)
+	}
+	if e.Err != nil {
+		writeLogfmtString(b, "error", e.Err.Error())
+	}
+	for _, f := range e.Fields {
+		writeLogfmtField(b, f.Key, f.Value)
+	}
+}
+
+// encodeCloudLogging writes the Entry with the special fields of Cloud Logging, the others are the ones of encodeJSON.
+func encodeCloudLogging(b *bytes.Buffer, e Entry, c Config) {
+	b.WriteByte('{')
+	writeJSONString(b, "severity", cloudSeverity(e.Level), true)
+	writeJSONTime(b, "timestamp", e.Time.UTC(), time.RFC3339Nano)
+	writeJSONString(b, "message", e.message(), false)
+	if file, line, ok := splitLocation(e.Location); ok {
+		writeJSONField(b, "logging.googleapis.com/sourceLocation", map[string]string{"file": file, "line": line}, false)
+	}
+	writeJSONField(b, SchemaVersionKey, SchemaVersion, false)
+	if e.Logger != "" {
+		writeJSONString(b, "logger", e.Logger, false)
+	}
+	if e.Trace {
+		writeJSONTrace(b, e, c.DurationFormat)
+	}
+	if e.Err != nil {
+		writeJSONString(b, "error", e.Err.Error(), false)
+	}
+	for _, f := range e.Fields {
+		writeJSONField(b, f.Key, f.Value, false)
+	}
+	b.WriteByte('}')
+}
+
+// cloudSeverity maps the level to the LogSeverity of Cloud Logging.
+func cloudSeverity(level string) string {
+	switch level {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelWarn:
+		return "WARNING"
+	case LevelError:
+		return "ERROR"
+	}
+	return "INFO"
+}
+
+// splitLocation splits file.go:line, ok is false when there is no line.
+func splitLocation(location string) (file, line string, ok bool) {
+	i := strings.LastIndexByte(location, ':')
+	if i < 0 {
+		return location, "", false
+	}
+	if _, err := strconv.Atoi(location[i+1:]); err != nil {
+		return location, "", false
+	}
+	return location[:i], location[i+1:], true
+}
+
+func writeLogfmtField(b *bytes.Buffer, key string, value interface{}) {
+	var scratch [32]byte
+	switch v := value.(type) {
+	case string:
+		writeLogfmtString(b, key, v)
+	case int:
+		writeLogfmtBytes(b, key, strconv.AppendInt(scratch[:0], int64(v), 10))
+	case int64:
+		writeLogfmtBytes(b, key, strconv.AppendInt(scratch[:0], v, 10))
+	case bool:
+		writeLogfmtBytes(b, key, strconv.AppendBool(scratch[:0], v))
+	default:
+		writeLogfmtString(b, key, fmt.Sprint(value))
+	}
+}
+
+// writeLogfmtString writes key=value, quoting the ones logfmtQuoted reports.
+func writeLogfmtString(b *bytes.Buffer, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	writeLogfmtValue(b, key)
+	b.WriteByte('=')
+	writeLogfmtValue(b, value)
+}
+
+// writeLogfmtBytes is writeLogfmtString for the values formatted on the stack by the strconv and time Append functions.
+func writeLogfmtBytes(b *bytes.Buffer, key string, value []byte) {
+	// the ranged conversion doesn't allocate, only the values to quote are copied.
+	for _, r := range string(value) {
+		if logfmtQuote(r) {
+			writeLogfmtString(b, key, string(value))
+			return
+		}
+	}
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	writeLogfmtValue(b, key)
+	b.WriteByte('=')
+	b.Write(value)
+}
+
+// writeLogfmtValue writes s, quoted in the spare capacity of b when logfmtQuoted reports it.
+func writeLogfmtValue(b *bytes.Buffer, s string) {
+	if !logfmtQuoted(s) {
+		b.WriteString(s)
+		return
+	}
+	b.Grow(len(s) + 2)
+	buf := b.Bytes()
+	b.Write(strconv.AppendQuote(buf[len(buf):len(buf)], s))
+}
+
+// logfmtQuoted reports whether s is empty or has spaces, quotes, equals or control characters.
+func logfmtQuoted(s string) bool {
+	if s == "" {
+		return true
+	}
+	for _, r := range s {
+		if logfmtQuote(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func logfmtQuote(r rune) bool {
+	return r <= ' ' || r == '=' || r == '"' || r == utf8.RuneError || r == 0x7f
+}
+
+// writeJSONTime writes the time formatted with layout without allocating it, the layouts don't need escaping.
+func writeJSONTime(b *bytes.Buffer, key string, t time.Time, layout string) {
+	var scratch [64]byte
+	b.WriteByte(',')
+	appendJSONString(b, key)
+	b.WriteString(`:"`)
+	b.Write(t.AppendFormat(scratch[:0], layout))
+	b.WriteByte('"')
+}
+
+// writeJSONString is writeJSONField for the string values, which would be allocated to be boxed in an interface.
+func writeJSONString(b *bytes.Buffer, key, value string, first bool) {
+	if !first {
+		b.WriteByte(',')
+	}
+	appendJSONString(b, key)
+	b.WriteByte(':')
+	appendJSONString(b, value)
+}
+
+// writeJSONTrace writes the duration, rows and sql of a trace, the key of the duration is the one of its format:
+// duration (auto), duration_ns or duration_ms.
+func writeJSONTrace(b *bytes.Buffer, e Entry, f DurationFormat) {
+	var scratch [32]byte
+	key, value := "duration_ms", []byte(nil)
+	switch f {
+	case DurationAuto:
+		key = "duration"
+		// the text of the durations needs no escaping.
+		value = append(f.appendText(append(scratch[:0], '"'), e.Duration), '"')
+	case DurationNanos:
+		key = "duration_ns"
+		value = strconv.AppendInt(scratch[:0], e.Duration.Nanoseconds(), 10)
+	}
+	b.WriteByte(',')
+	appendJSONString(b, key)
+	b.WriteByte(':')
+	if value != nil {
+		b.Write(value)
+	} else if ms := float64(e.Duration.Nanoseconds()) / 1e6; !appendJSONFloat(b, ms) {
+		b.Write(marshalJSON(ms))
+	}
+
+	b.WriteString(`,"rows":`)
+	b.Write(strconv.AppendInt(scratch[:0], e.Rows, 10))
+	writeJSONString(b, "sql", e.SQL, false)
+}
+
+func writeJSONField(b *bytes.Buffer, key string, value interface{}, first bool) {
+	if !first {
+		b.WriteByte(',')
+	}
+	appendJSONString(b, key)
+	b.WriteByte(':')
+
+	if !appendJSONValue(b, value) {
+		b.Write(marshalJSON(value))
+	}
+}
+
+// marshalJSON encodes v without escaping HTML, values that can't be encoded are written with %v.
+func marshalJSON(v interface{}) []byte {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		b.Reset()
+		_ = enc.Encode(fmt.Sprint(v))
+	}
+	return bytes.TrimRight(b.Bytes(), "\n")
+}
+
+// splitArgs separates the printf arguments of format from the trailing key value pairs.
+// A key without value is kept under "!BADKEY" as slog does.
+func splitArgs(format string, data []interface{}) ([]interface{}, []Field) {
+	n := countVerbs(format)
+	if n >= len(data) {
+		return data, nil
+	}
+
+	args, rest := data[:n], data[n:]
+	fields := make([]Field, 0, (len(rest)+1)/2)
+	for len(rest) > 0 {
+		key, ok := rest[0].(string)
+		if !ok || len(rest) == 1 {
+			fields = append(fields, Field{Key: "!BADKEY", Value: rest[0]})
+			rest = rest[1:]
+			continue
+		}
+		fields = append(fields, Field{Key: key, Value: rest[1]})
+		rest = rest[2:]
+	}
+	return args, fields
+}
+
+// countVerbs returns how many arguments the printf format consumes.
+func countVerbs(format string) int {
+	n := 0
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+		if i < len(format) && format[i] == '%' {
+			continue
+		}
+		// flags, width and precision, '*' consumes an argument.
+		for ; i < len(format); i++ {
+			c := format[i]
+			if c == '*' {
+				n++
+				continue
+			}
+			if c == '+' || c == '-' || c == '#' || c == ' ' || c == '.' || (c >= '0' && c <= '9') {
+				continue
+			}
+			break
+		}
+		if i < len(format) {
+			n++
+		}
+	}
+	return n
+}
+
+// MarshalJSON encodes the infos with the keys of the structured formats, Err is written as its message.
+// It is the payload sent by the sinks.
+func (g GormInfos) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	writeJSONField(&b, SchemaVersionKey, SchemaVersion, true)
+	writeJSONField(&b, "caller", g.Location, false)
+	writeJSONField(&b, "duration_ms", g.QueryDuration, false)
+	if !g.Begin.IsZero() {
+		writeJSONField(&b, "begin", g.Begin.Format(time.RFC3339Nano), false)
+		writeJSONField(&b, "end", g.End.Format(time.RFC3339Nano), false)
+	}
+	writeJSONField(&b, "rows", g.AffectedRows, false)
+	writeJSONField(&b, "sql", g.Sql, false)
+	if g.Fingerprint != "" {
+		writeJSONField(&b, "fingerprint", g.Fingerprint, false)
+	}
+	if g.Operation != "" {
+		writeJSONField(&b, "operation", g.Operation, false)
+	}
+	if len(g.Tables) > 0 {
+		writeJSONField(&b, "tables", g.Tables, false)
+	}
+	if g.Executions > 0 {
+		writeJSONField(&b, "executions", g.Executions, false)
+	}
+	if g.Repeats > 0 {
+		writeJSONField(&b, "repeats", g.Repeats, false)
+	}
+	if g.Err != nil {
+		writeJSONField(&b, "error", g.Err.Error(), false)
+	}
+	if g.ErrClass != "" {
+		writeJSONField(&b, "error_class", g.ErrClass, false)
+	}
+	if g.TraceID != "" {
+		writeJSONField(&b, "trace_id", g.TraceID, false)
+	}
+	if g.SpanID != "" {
+		writeJSONField(&b, "span_id", g.SpanID, false)
+	}
+	if g.RequestID != "" {
+		writeJSONField(&b, "request_id", g.RequestID, false)
+	}
+	if g.Tenant != "" {
+		writeJSONField(&b, "tenant", g.Tenant, false)
+	}
+	if g.Logger != "" {
+		writeJSONField(&b, "logger", g.Logger, false)
+	}
+	if len(g.Fields) > 0 {
+		writeJSONField(&b, "fields", g.Fields, false)
+	}
+	if g.Plan != "" {
+		writeJSONField(&b, "plan", g.Plan, false)
+	}
+	if g.Statement != nil {
+		writeJSONField(&b, "statement", g.Statement, false)
+	}
+	if g.Lock != nil {
+		writeJSONField(&b, "lock", map[string]interface{}{
+			"kind":      g.Lock.Kind,
+			"wait_ms":   float64(g.Lock.Wait.Nanoseconds()) / 1e6,
+			"competing": g.Lock.Competing,
+		}, false)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// fingerprint returns the Fingerprint, computed when the infos weren't built by the logger.
+func (g GormInfos) fingerprint() string {
+	if g.Fingerprint != "" {
+		return g.Fingerprint
+	}
+	return fingerprint(g.Sql)
+}
+
+// keyValues returns the fields of the entry as alternated keys and values,
+// used by the adapters of the structured loggers.
+func (e Entry) keyValues() []interface{} {
+	kv := make([]interface{}, 0, 2*len(e.Fields)+10)
+	if e.Location != "" {
+		kv = append(kv, "caller", e.Location)
+	}
+	if e.Logger != "" {
+		kv = append(kv, "logger", e.Logger)
+	}
+	if e.Trace {
+		kv = append(kv, "duration", e.Duration, "rows", e.Rows, "sql", e.SQL)
+	}
+	if e.Err != nil {
+		kv = append(kv, "error", e.Err)
+	}
+	for _, f := range e.Fields {
+		kv = append(kv, f.Key, f.Value)
+	}
+	return kv
+}
+
+// message returns the message of the entry, "sql" for the traces without one.
+func (e Entry) message() string {
+	if e.Trace && e.Message == "" {
+		return "sql"
+	}
+	return e.Message
+}
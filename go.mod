@@ -2,4 +2,4 @@ module cgLogger
 
 go 1.16
 
-require gorm.io/gorm v1.21.11 // indirect
+require gorm.io/gorm v1.21.11
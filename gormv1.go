@@ -0,0 +1,80 @@
+package cgLogger
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	lg "gorm.io/gorm/logger"
+)
+
+var numericPlaceholder = regexp.MustCompile(`\$(\d+)`)
+
+// GormV1Logger implements the logger interface of jinzhu/gorm (v1) on top of a CInterface,
+// so v1 services share the triggers, formats and writers of the v2 ones:
+//
+//	db.SetLogger(cgLogger.GormV1(Default))
+//	db.LogMode(true)
+type GormV1Logger struct {
+	l CInterface
+}
+
+// GormV1 wraps l to be used as a jinzhu/gorm logger.
+func GormV1(l CInterface) GormV1Logger {
+	return GormV1Logger{l: l}
+}
+
+// Print receives the values logged by gorm v1:
+//
+//	"sql", location, duration, sql, vars, rowsAffected
+//	"log", location, values...
+//	"error", location, err
+func (a GormV1Logger) Print(values ...interface{}) {
+	if noop || len(values) < 3 {
+		return
+	}
+
+	level, _ := values[0].(string)
+	location, _ := values[1].(string)
+
+	if level == "sql" && len(values) >= 6 {
+		duration, _ := values[2].(time.Duration)
+		sql, _ := values[3].(string)
+		vars, _ := values[4].([]interface{})
+		rows, _ := values[5].(int64)
+
+		sql = explainSQL(sql, vars)
+		a.trace(location, time.Now().Add(-duration), sql, rows, nil)
+		return
+	}
+
+	// v1 logs the errors apart from the statement, they are traced without sql.
+	if err, ok := values[2].(error); ok && len(values) == 3 {
+		a.trace(location, time.Now(), "", -1, err)
+		return
+	}
+
+	msg := strings.TrimSpace(fmt.Sprintln(values[2:]...))
+	if l, ok := a.l.(*customLogger); ok {
+		l.printf(context.Background(), lg.Info, LevelInfo, location, "%s", []interface{}{msg})
+		return
+	}
+	a.l.Info(context.Background(), "%s", msg)
+}
+
+func (a GormV1Logger) trace(location string, begin time.Time, sql string, rows int64, err error) {
+	traceAt(a.l, context.Background(), location, begin, func() (string, int64) { return sql, rows }, err)
+}
+
+// explainSQL binds the vars in the statement, it accepts both ? and $n placeholders.
+func explainSQL(sql string, vars []interface{}) string {
+	if len(vars) == 0 {
+		return sql
+	}
+	if numericPlaceholder.MatchString(sql) {
+		return lg.ExplainSQL(sql, numericPlaceholder, `'`, vars...)
+	}
+	return lg.ExplainSQL(sql, nil, `'`, vars...)
+}
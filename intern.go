@@ -0,0 +1,48 @@
+package cgLogger
+
+import (
+	"container/list"
+	"sync"
+)
+
+// maxInternedFingerprints bounds the fingerprints kept by the interning cache, the least recently used one is
+// forgotten for a new one.
+const maxInternedFingerprints = 4096
+
+// fingerprints interns the fingerprints: the executions of the same query shape share one string instead of
+// allocating it each time.
+var fingerprints = newInternCache(maxInternedFingerprints)
+
+// internCache is a bounded LRU set of strings.
+type internCache struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List // of string, the most recently used first
+	entries map[string]*list.Element
+}
+
+func newInternCache(size int) *internCache {
+	return &internCache{size: size, order: list.New(), entries: make(map[string]*list.Element, size)}
+}
+
+// intern returns the string of b, the same one for the same bytes while it is in the cache.
+// Only a miss allocates.
+func (c *internCache) intern(b []byte) string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.entries[string(b)]; ok {
+		c.order.MoveToFront(e)
+		return e.Value.(string)
+	}
+
+	s := string(b)
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(string))
+	}
+	c.entries[s] = c.order.PushFront(s)
+	return s
+}
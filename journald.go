@@ -0,0 +1,139 @@
+package cgLogger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// JournaldSocket is the socket of the native journal protocol.
+const JournaldSocket = "/run/systemd/journal/socket"
+
+// JournaldWriter sends the lines to systemd-journald with a PRIORITY per level and the fields
+// SQL, DURATION_MS, ROWS, ERROR, CODE_FILE and CODE_LINE, so they can be filtered with journalctl:
+//
+//	journalctl SYSLOG_IDENTIFIER=orders PRIORITY=4 -o verbose
+//
+// The fields of Info/Warn/Error are sent uppercased, ex: tenant -> TENANT.
+// Use it with Colorful false, the text lines are sent as they are.
+type JournaldWriter struct {
+	conn       net.Conn
+	identifier string
+}
+
+// NewJournaldWriter connects to the journal socket, identifier is the SYSLOG_IDENTIFIER (defaults to the executable name).
+func NewJournaldWriter(identifier string) (*JournaldWriter, error) {
+	conn, err := net.Dial("unixgram", JournaldSocket)
+	if err != nil {
+		return nil, err
+	}
+	if identifier == "" {
+		identifier = filepath.Base(os.Args[0])
+	}
+	return &JournaldWriter{conn: conn, identifier: identifier}, nil
+}
+
+// Printf sends the lines that don't come as entries with the info priority.
+func (w *JournaldWriter) Printf(format string, data ...interface{}) {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", fmt.Sprintf(format, data...))
+	writeJournalField(&b, "PRIORITY", "6")
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", w.identifier)
+	w.send(b.Bytes())
+}
+
+// WriteEntry sends e with its fields.
+func (w *JournaldWriter) WriteEntry(e Entry) {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", journalMessage(e))
+	writeJournalField(&b, "PRIORITY", journalPriority(e.Level))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", w.identifier)
+	if file, line, ok := splitLocation(e.Location); ok {
+		writeJournalField(&b, "CODE_FILE", file)
+		writeJournalField(&b, "CODE_LINE", line)
+	}
+	if e.Trace {
+		writeJournalField(&b, "SQL", e.SQL)
+		writeJournalField(&b, "DURATION_MS", strconv.FormatFloat(float64(e.Duration.Nanoseconds())/1e6, 'f', 3, 64))
+		writeJournalField(&b, "ROWS", strconv.FormatInt(e.Rows, 10))
+	}
+	if e.Err != nil {
+		writeJournalField(&b, "ERROR", e.Err.Error())
+	}
+	for _, f := range e.Fields {
+		writeJournalField(&b, journalKey(f.Key), fmt.Sprint(f.Value))
+	}
+	w.send(b.Bytes())
+}
+
+// Close closes the socket.
+func (w *JournaldWriter) Close() error {
+	return w.conn.Close()
+}
+
+// send writes the datagram, the lines are lost if journald is not reachable.
+func (w *JournaldWriter) send(b []byte) {
+	_, _ = w.conn.Write(b)
+}
+
+func journalMessage(e Entry) string {
+	if !e.Trace {
+		return e.Message
+	}
+	switch {
+	case e.Err != nil:
+		return e.Err.Error() + ": " + e.SQL
+	case e.Message != "":
+		return e.Message + ": " + e.SQL
+	}
+	return e.SQL
+}
+
+// journalPriority maps the level to the syslog priority.
+func journalPriority(level string) string {
+	switch level {
+	case LevelDebug:
+		return "7"
+	case LevelWarn:
+		return "4"
+	case LevelError:
+		return "3"
+	}
+	return "6"
+}
+
+// journalKey turns key into a valid journal field name: uppercase letters, digits and
+// underscores, not starting with an underscore (reserved to journald).
+func journalKey(key string) string {
+	b := []byte(strings.ToUpper(key))
+	for i, c := range b {
+		if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	k := strings.TrimLeft(string(b), "_")
+	if k == "" || k[0] >= '0' && k[0] <= '9' {
+		k = "F_" + k
+	}
+	return k
+}
+
+// writeJournalField writes KEY=value, or the binary form when the value has a new line.
+func writeJournalField(b *bytes.Buffer, key, value string) {
+	b.WriteString(key)
+	if !strings.ContainsRune(value, '\n') {
+		b.WriteByte('=')
+		b.WriteString(value)
+		b.WriteByte('\n')
+		return
+	}
+	b.WriteByte('\n')
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value)
+	b.WriteByte('\n')
+}
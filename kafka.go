@@ -0,0 +1,126 @@
+package cgLogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// KafkaMessage is an event to publish, Key is the fingerprint of the query so the events
+// of a query go to the same partition.
+type KafkaMessage struct {
+	Key   []byte
+	Value []byte
+}
+
+// KafkaProducer publishes a batch of messages, implement it with your client. Ex with segmentio/kafka-go:
+//
+//	type producer struct{ w *kafka.Writer }
+//
+//	func (p producer) Produce(ctx context.Context, topic string, msgs []cgLogger.KafkaMessage) error {
+//		out := make([]kafka.Message, len(msgs))
+//		for i, m := range msgs {
+//			out[i] = kafka.Message{Topic: topic, Key: m.Key, Value: m.Value}
+//		}
+//		return p.w.WriteMessages(ctx, out...)
+//	}
+type KafkaProducer interface {
+	Produce(ctx context.Context, topic string, messages []KafkaMessage) error
+}
+
+// KafkaConfig configures a KafkaSink, Producer and Topic are required.
+type KafkaConfig struct {
+	Producer KafkaProducer
+	Topic    string
+
+	// BatchSize is the max messages per Produce call, defaults to 500.
+	BatchSize int
+	// FlushInterval is the max time an event waits to be sent, defaults to 5s.
+	FlushInterval time.Duration
+	// QueueSize is the max of events waiting to be sent, the new ones are dropped when full. Defaults to 10000.
+	QueueSize int
+	// Timeout of each Produce call, defaults to 10s.
+	Timeout time.Duration
+
+	// OnError receives the failed batches, by default they are ignored.
+	OnError func(error)
+}
+
+// KafkaSink publishes the traces as JSON events (see GormInfos.MarshalJSON, plus the time) in batches.
+// Pass Trigger to AlwaysTrigger for every trace, or to SlowTrigger and ErrorTrigger for the slow and failed ones.
+//
+//	sink, err := cgLogger.NewKafkaSink(cgLogger.KafkaConfig{Producer: producer{w}, Topic: "gorm-queries"})
+//	if err != nil {
+//		return err
+//	}
+//	defer sink.Close()
+//	logger.AlwaysTrigger(sink.Trigger)
+type KafkaSink struct {
+	config  KafkaConfig
+	batcher *batcher
+}
+
+// kafkaEvent is an event waiting to be published.
+type kafkaEvent struct {
+	at    time.Time
+	infos GormInfos
+}
+
+// NewKafkaSink starts the goroutine publishing the batches, Close stops it.
+// It fails without Producer or Topic.
+func NewKafkaSink(config KafkaConfig) (*KafkaSink, error) {
+	if config.Producer == nil {
+		return nil, errors.New("cgLogger: kafka: Producer is required")
+	}
+	if config.Topic == "" {
+		return nil, errors.New("cgLogger: kafka: Topic is required")
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+
+	s := &KafkaSink{config: config}
+	s.batcher = newBatcher(config.QueueSize, config.BatchSize, config.FlushInterval, s.send)
+	return s, nil
+}
+
+// Trigger queues the event.
+func (s *KafkaSink) Trigger(g GormInfos) {
+	s.batcher.add(kafkaEvent{at: time.Now(), infos: g})
+}
+
+// Dropped returns how many events were dropped because the queue was full.
+func (s *KafkaSink) Dropped() int64 {
+	return s.batcher.dropCount()
+}
+
+// Flush publishes the queued events and waits for them.
+func (s *KafkaSink) Flush() {
+	s.batcher.sync()
+}
+
+// Close publishes the queued events and stops the sink, the producer is not closed.
+func (s *KafkaSink) Close() error {
+	s.batcher.close()
+	return nil
+}
+
+func (s *KafkaSink) send(batch []interface{}) {
+	messages := make([]KafkaMessage, len(batch))
+	for i, v := range batch {
+		e := v.(kafkaEvent)
+		doc, _ := e.infos.MarshalJSON()
+		messages[i] = KafkaMessage{
+			Key:   []byte(e.infos.fingerprint()),
+			Value: []byte(fmt.Sprintf(`{"time":%q,%s`, e.at.UTC().Format(time.RFC3339Nano), doc[1:])),
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+
+	if err := s.config.Producer.Produce(ctx, s.config.Topic, messages); err != nil && s.config.OnError != nil {
+		s.config.OnError(fmt.Errorf("cgLogger: kafka: %d messages lost: %w", len(messages), err))
+	}
+}
@@ -0,0 +1,33 @@
+package cgLogger
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// PprofLabels sets the pprof labels cglogger.fingerprint, cglogger.operation and cglogger.table while the
+// logger handles a query once its statement is parsed and redacted (the triggers run inline, the formatting
+// and the writing of the line), so the CPU profiles can be sliced by query shape. The labels of the context
+// are restored after. The triggers run by TriggerWorkers and the Async writes are not labeled.
+// It is copied by LogMode and Named: call it before them, the loggers already derived keep their setting.
+func (l *customLogger) PprofLabels(on bool) CInterface {
+	l.pprofLabels = on
+	return l
+}
+
+// setLabels labels the goroutine for g, returning the function restoring the labels of ctx.
+func setLabels(ctx context.Context, g GormInfos) (restore func()) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	table := ""
+	if len(g.Tables) > 0 {
+		table = g.Tables[0]
+	}
+	pprof.SetGoroutineLabels(pprof.WithLabels(ctx, pprof.Labels(
+		"cglogger.fingerprint", g.Fingerprint,
+		"cglogger.operation", g.Operation,
+		"cglogger.table", table,
+	)))
+	return func() { pprof.SetGoroutineLabels(ctx) }
+}
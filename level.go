@@ -0,0 +1,46 @@
+package cgLogger
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	lg "gorm.io/gorm/logger"
+)
+
+type levelKey struct{}
+
+// LogLevelContext returns a context whose queries and messages are logged at level, whatever the level of the logger:
+//
+//	if req.Header.Get("X-Debug-SQL") == "1" {
+//		ctx = cgLogger.LogLevelContext(ctx, logger.Info)
+//	}
+//	db.WithContext(ctx).Find(&users)
+func LogLevelContext(ctx context.Context, level lg.LogLevel) context.Context {
+	return context.WithValue(ctx, levelKey{}, level)
+}
+
+// levelFor returns the level of ctx set with LogLevelContext, or the level in effect.
+func (l customLogger) levelFor(ctx context.Context) lg.LogLevel {
+	if ctx != nil {
+		if level, ok := ctx.Value(levelKey{}).(lg.LogLevel); ok {
+			return level
+		}
+	}
+	return l.level()
+}
+
+// ParseLevel returns the gorm level of "silent", "error", "warn" (or "warning") and "info", case insensitive.
+func ParseLevel(s string) (lg.LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "silent":
+		return lg.Silent, nil
+	case "error":
+		return lg.Error, nil
+	case "warn", "warning":
+		return lg.Warn, nil
+	case "info":
+		return lg.Info, nil
+	}
+	return 0, fmt.Errorf("cgLogger: unknown log level %q", s)
+}
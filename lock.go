@@ -0,0 +1,142 @@
+package cgLogger
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Kinds of LockInfo.
+const (
+	LockDeadlock    = "deadlock"
+	LockWaitTimeout = "lock_wait_timeout"
+)
+
+// LockInfo is set in GormInfos when the query failed waiting for a lock.
+type LockInfo struct {
+	// Kind is LockDeadlock or LockWaitTimeout.
+	Kind string
+	// Wait is how long the query waited before the driver gave up.
+	Wait time.Duration
+	// Competing is the fingerprint of the last statement taking locks before this one started.
+	// It is a best effort guess as the drivers don't report the blocking statement, empty if unknown.
+	Competing string
+}
+
+// LockContention aggregates the lock errors of a query fingerprint, see LockReport.
+type LockContention struct {
+	Fingerprint string
+	Sample      string
+	Deadlocks   int64
+	Timeouts    int64
+	TotalWait   time.Duration
+	MaxWait     time.Duration
+	// Competing counts the competing fingerprints seen, see LockInfo.Competing.
+	Competing map[string]int64
+}
+
+// lockKind returns the kind of lock error, "" if err isn't one.
+func lockKind(err error) string {
+	if err == nil {
+		return ""
+	}
+	switch sqlState(err) {
+	case "40P01":
+		return LockDeadlock
+	case "55P03":
+		return LockWaitTimeout
+	}
+	switch mysqlNumber(err) {
+	case "1213":
+		return LockDeadlock
+	case "1205":
+		return LockWaitTimeout
+	}
+	if strings.Contains(err.Error(), "database is locked") {
+		return LockWaitTimeout
+	}
+	return ""
+}
+
+// lockReport keeps the lock errors by fingerprint and the last statement that took locks.
+// It is shared between the loggers derived with LogMode.
+type lockReport struct {
+	mu        sync.Mutex
+	byQuery   map[string]*LockContention
+	lastWrite string
+	lastAt    time.Time
+}
+
+func newLockReport() *lockReport {
+	return &lockReport{byQuery: map[string]*LockContention{}}
+}
+
+// observe records the statement, returning the LockInfo when err is a lock error.
+func (r *lockReport) observe(sql, fp string, begin time.Time, elapsed time.Duration, err error) *LockInfo {
+	kind := lockKind(err)
+	if kind == "" {
+		if err == nil && isWrite(sql) {
+			r.mu.Lock()
+			r.lastWrite, r.lastAt = sql, begin.Add(elapsed)
+			r.mu.Unlock()
+		}
+		return nil
+	}
+
+	info := &LockInfo{Kind: kind, Wait: elapsed}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.lastWrite != "" && r.lastAt.Before(begin) {
+		if competing := fingerprint(r.lastWrite); competing != fp {
+			info.Competing = competing
+		}
+	}
+
+	c := r.byQuery[fp]
+	if c == nil {
+		c = &LockContention{Fingerprint: fp, Sample: sql, Competing: map[string]int64{}}
+		r.byQuery[fp] = c
+	}
+	if kind == LockDeadlock {
+		c.Deadlocks++
+	} else {
+		c.Timeouts++
+	}
+	c.TotalWait += elapsed
+	if elapsed > c.MaxWait {
+		c.MaxWait = elapsed
+	}
+	if info.Competing != "" {
+		c.Competing[info.Competing]++
+	}
+
+	return info
+}
+
+// report returns a copy of the contentions, the most frequent first.
+func (r *lockReport) report() []LockContention {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make([]LockContention, 0, len(r.byQuery))
+	for _, c := range r.byQuery {
+		cp := *c
+		cp.Competing = make(map[string]int64, len(c.Competing))
+		for k, v := range c.Competing {
+			cp.Competing[k] = v
+		}
+		out = append(out, cp)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		ci, cj := out[i].Deadlocks+out[i].Timeouts, out[j].Deadlocks+out[j].Timeouts
+		if ci != cj {
+			return ci > cj
+		}
+		return out[i].TotalWait > out[j].TotalWait
+	})
+	return out
+}
@@ -2,12 +2,16 @@ package cgLogger
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	lg "gorm.io/gorm/logger"
 	"gorm.io/gorm/utils"
+	"io"
 	"log"
+	"net/http"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -27,6 +31,7 @@ const (
 	MagentaBold = "\033[35;1m"
 	RedBold     = "\033[31;1m"
 	YellowBold  = "\033[33;1m"
+	Bold        = "\033[1m"
 )
 
 // GormInfos are the data passed to the custom functions
@@ -36,8 +41,60 @@ type GormInfos struct {
 	QueryDuration float64
 	Sql           string
 	Err           error
+	// Begin and End are the start and the end of the query, Elapsed its duration.
+	Begin   time.Time
+	End     time.Time
+	Elapsed time.Duration
+	// LogLevel is the level in effect for the query, see LogLevelContext.
+	LogLevel lg.LogLevel
+	// ErrClass is the category of Err (ErrClassNotFound, ErrClassDeadlock...), empty without error.
+	ErrClass string
+	// Fingerprint is the normalized statement (literals replaced by ?, whitespace collapsed),
+	// equal for the executions of the same query shape.
+	Fingerprint string
+	// Operation is the kind of statement: OperationSelect, OperationInsert...
+	Operation string
+	// Tables are the tables read or written by the statement, the main one first, without quotes nor schema.
+	Tables []string
+	// Executions is how many times the query shape (Fingerprint) ran in the request, counting this one.
+	// It is 0 out of the contexts marked with StartRequest, and for the shapes after the first 1024 of the request.
+	Executions int
+	// Repeats is how many times the identical statement (same values) ran in the request, counting this one.
+	// It is 0 out of the contexts marked with StartRequest, and for the statements after the first 128 of a shape.
+	Repeats int
+	// Lock is set when the query failed by a deadlock or a lock wait timeout.
+	Lock *LockInfo
+	// TraceID and SpanID are read from the context by the TraceIDs extractor.
+	TraceID string
+	SpanID  string
+	// RequestID is read from the context by the RequestID extractor.
+	RequestID string
+	// Tenant is read from the context key given to Tenant.
+	Tenant string
+	// Logger is the name of the Named logger that traced the query.
+	Logger string
+	// Fields are read from the context by the ContextFields extractors.
+	Fields map[string]interface{}
+	// Context is the one given to Trace, to read the deadline, the span or the request values in the triggers.
+	// It isn't encoded by MarshalJSON.
+	Context context.Context
+	// Plan is the EXPLAIN of the slow queries, see ExplainSlow.
+	Plan string
+	// Statement is the gorm Statement of the query, set when the Plugin is used.
+	Statement *StatementInfo
 }
 
+// Operations of GormInfos.Operation.
+const (
+	OperationSelect = "SELECT"
+	OperationInsert = "INSERT"
+	OperationUpdate = "UPDATE"
+	OperationDelete = "DELETE"
+	// OperationDDL are the schema changes: CREATE, ALTER, DROP, TRUNCATE...
+	OperationDDL   = "DDL"
+	OperationOther = "OTHER"
+)
+
 // Writer log writer interface
 type Writer interface {
 	Printf(string, ...interface{})
@@ -48,6 +105,45 @@ type Config struct {
 	Colorful                  bool
 	IgnoreRecordNotFoundError bool
 	LogLevel                  lg.LogLevel
+	Format                    Format
+	// ParameterizedQueries logs the statements without their values: gorm (>= 1.25) doesn't bind them,
+	// see ParamsFilter, and the literals of the statements already bound are replaced by ?, between single quotes
+	// or between the double quotes of the sqlite and mysql dialects. Older gorm versions always bind them.
+	ParameterizedQueries bool
+	// OperationThresholds override the SlowThreshold by GormInfos.Operation, ex: seconds for OperationInsert
+	// when bulk loads are expected. It must not be modified once the Config is given to the logger.
+	OperationThresholds map[string]time.Duration
+	// TableThresholds override the SlowThreshold (and the OperationThresholds) by table, case insensitive and
+	// without schema. With several tables the highest threshold wins. It must not be modified once the Config is given.
+	TableThresholds map[string]time.Duration
+	// CriticalThreshold is a second tier of slow queries, logged as CRITICAL SLOW SQL with their own colors
+	// and given to the CriticalTrigger. 0 turns it off.
+	CriticalThreshold time.Duration
+	// SampleInfo writes 1 of every SampleInfo successful and not slow traces of the Info level, to keep Info on
+	// in production. The errors and slow queries are always written, the triggers and Stats see all the queries.
+	SampleInfo int
+	// PrettySQL breaks the statements of the text lines in several indented lines, for local development.
+	PrettySQL bool
+	// Templates replace the layouts of the text format, to match the grammar of the other logs of the service.
+	Templates *Templates
+	// TimeFormat is the layout of the time written before the caller in the text format, so the lines don't depend
+	// on the flags of the log.Logger, and of the time of the JSON and logfmt formats (RFC3339Nano by default).
+	TimeFormat string
+	// UTC writes the times of the lines in UTC instead of the local time.
+	UTC bool
+	// DurationFormat is how the duration of the traces is written, milliseconds by default.
+	DurationFormat DurationFormat
+	// CallerSkip skips more frames after the ones of gorm when resolving the caller, ex: 1 when the queries
+	// are issued by a helper of the service and the location must be the caller of the helper.
+	CallerSkip int
+	// CallerSkipPrefixes skip the frames of the functions starting with the prefixes when resolving the caller,
+	// ex: "github.com/acme/orders/repository." for the repository layer of the service.
+	CallerSkipPrefixes []string
+	// DisableCaller doesn't resolve the caller of the lines (GormInfos.Location is empty), the walk of the stack
+	// shows up in the profiles when every query is logged at Info level.
+	DisableCaller bool
+	// Theme are the colors of the text lines with Colorful, the ones of gorm by default.
+	Theme *Theme
 }
 
 // CInterface customLogger interface
@@ -61,128 +157,543 @@ type CInterface interface {
 	SlowTrigger(f func(g GormInfos), duration time.Duration) CInterface
 	ErrorTrigger(f func(g GormInfos)) CInterface
 	ConsiderNotFound(b bool) CInterface
+	MaintenanceWindow(start, end time.Time) CInterface
+	Maintenance(on bool) CInterface
+	SuppressedTriggers() int64
+	Schedule(s LevelSchedule) CInterface
+	Debugf(format string, data ...interface{})
+	Infof(format string, data ...interface{})
+	Warnf(format string, data ...interface{})
+	Errorf(format string, data ...interface{})
+	Level() lg.LogLevel
+	SlowThreshold() time.Duration
+	Format() Format
+	OnDuplicateKey(f func(g GormInfos)) CInterface
+	OnConstraintViolation(f func(g GormInfos)) CInterface
+	ReadInWriteTxTrigger(f func(g GormInfos), duration time.Duration) CInterface
+	CanceledTrigger(f func(g GormInfos)) CInterface
+	DeadlineTrigger(f func(g GormInfos), ratio float64) CInterface
+	OnErrorClass(class string, f func(g GormInfos)) CInterface
+	ErrorRateTrigger(f func(g GormInfos), threshold float64, window time.Duration) CInterface
+	CriticalTrigger(f func(g GormInfos)) CInterface
+	OnDeadlock(f func(g GormInfos)) CInterface
+	OnTimeout(f func(g GormInfos)) CInterface
+	OnConnectionError(f func(g GormInfos)) CInterface
+	DangerTrigger(f func(g GormInfos)) CInterface
+	RowsTrigger(f func(g GormInfos), minRows int64) CInterface
+	NPlusOneTrigger(f func(g GormInfos), threshold int) CInterface
+	DuplicateQueryTrigger(f func(g GormInfos)) CInterface
+	EndRequest(ctx context.Context) RequestSummary
+	OnRequestEnd(f func(ctx context.Context, s RequestSummary)) CInterface
+	Middleware(next http.Handler) http.Handler
+	LockReport() []LockContention
+	SummaryMode(on bool) CInterface
+	PrintSummary(w io.Writer) error
+	TrackSlowest(n int) CInterface
+	TopSlow() []SlowQuery
+	DumpTopSlow(interval time.Duration) (stop func())
+	TrackPercentiles(on bool) CInterface
+	Percentiles() []QueryPercentiles
+	WritePercentilesJSON(w io.Writer) error
+	KeepRecent(n int) CInterface
+	Recent() []GormInfos
+	Dump(w io.Writer) error
+	DebugHandler() http.Handler
+	PprofLabels(on bool) CInterface
+	ExplainSlow(db Queryer, config ExplainConfig) CInterface
+	Audit(w Writer, actor func(ctx context.Context) string) CInterface
+	WatchPool(db *sql.DB, interval time.Duration, f func(s PoolStats)) (stop func())
+	RecordSpans(r SpanRecorder) CInterface
+	TraceIDs(f func(ctx context.Context) (traceID, spanID string)) CInterface
+	RequestID(f func(ctx context.Context) string) CInterface
+	Tenant(key interface{}) CInterface
+	ContextFields(extractors ...ContextField) CInterface
+	PublishExpvar(name string) CInterface
+	Stats() Stats
+	ResetStats()
+	Async(bufferSize int, dropWhenFull bool) CInterface
+	DroppedLines() int64
+	Flush()
+	Close() error
+	TriggerWorkers(workers, queueSize int, policy OverflowPolicy) CInterface
+	DroppedTriggers() int64
+	OnTriggerError(f func(g GormInfos, err error)) CInterface
+	TriggerTimeout(d time.Duration) CInterface
+	AddAlwaysTrigger(name string, f func(g GormInfos)) CInterface
+	AddSlowTrigger(name string, f func(g GormInfos), duration time.Duration) CInterface
+	AddErrorTrigger(name string, f func(g GormInfos)) CInterface
+	RemoveTrigger(name string) CInterface
+	ClearTriggers() CInterface
+	SessionTriggers() CInterface
+	Named(name string) CInterface
+	Reload(config Config) CInterface
+	SetLogLevel(level lg.LogLevel) CInterface
+	Redact(rules ...RedactRule) CInterface
+	MaskColumns(columns ...string) CInterface
+	IgnoreQueries(patterns ...string) CInterface
+	IgnoreTables(names ...string) CInterface
+	SuppressHealthChecks(on bool) CInterface
+	RateLimit(n int, period time.Duration) CInterface
+	DedupErrors(window time.Duration) CInterface
+	WatchConfigFile(path string, interval time.Duration, onError func(err error)) (stop func())
 }
 
 var (
-	Default = New(
-		log.New(os.Stdout, "\r\n", log.LstdFlags), Config{
-			SlowThreshold: 200 * time.Millisecond,
-			LogLevel:      lg.Warn,
-			Colorful:      true,
-		},
-	)
+	Default = New(defaultWriter(), defaultConfig())
 )
 
+// defaultConfig is the configuration of Default, also used as the base of NewWithOptions.
+func defaultConfig() Config {
+	return Config{
+		SlowThreshold: 200 * time.Millisecond,
+		LogLevel:      lg.Warn,
+		Colorful:      true,
+	}
+}
+
+func defaultWriter() Writer {
+	return log.New(os.Stdout, "\r\n", log.LstdFlags)
+}
+
 // New is a "Copy" of the original logger except it implements the new methods.
 func New(writer Writer, config Config) CInterface {
-	var (
-		infoStr      = "%s\n[info] "
-		warnStr      = "%s\n[warn] "
-		errStr       = "%s\n[error] "
-		traceStr     = "%s\n[%.3fms] [rows:%v] %s"
-		traceWarnStr = "%s %s\n[%.3fms] [rows:%v] %s"
-		traceErrStr  = "%s %s\n[%.3fms] [rows:%v] %s"
-	)
-
-	if config.Colorful {
-		infoStr = Green + "%s\n" + Reset + Green + "[info] " + Reset
-		warnStr = BlueBold + "%s\n" + Reset + Magenta + "[warn] " + Reset
-		errStr = Magenta + "%s\n" + Reset + Red + "[error] " + Reset
-		traceStr = Green + "%s\n" + Reset + Yellow + "[%.3fms] " + BlueBold + "[rows:%v]" + Reset + " %s"
-		traceWarnStr = Green + "%s " + Yellow + "%s\n" + Reset + RedBold + "[%.3fms] " + Yellow + "[rows:%v]" + Magenta + " %s" + Reset
-		traceErrStr = RedBold + "%s " + MagentaBold + "%s\n" + Reset + Yellow + "[%.3fms] " + BlueBold + "[rows:%v]" + Reset + " %s"
+	return &customLogger{
+		Writer:      writer,
+		Execution:   Execution{registry: newTriggerRegistry()},
+		settings:    newSettings(config),
+		maintenance: &maintenance{},
+		locks:       newLockReport(),
+		summary:     newSummary(),
+		stats:       newStatsHolder(),
+		sampler:     &sampler{},
 	}
+}
 
-	return &customLogger{
-		Writer:       writer,
-		Config:       config,
-		infoStr:      infoStr,
-		warnStr:      warnStr,
-		errStr:       errStr,
-		traceStr:     traceStr,
-		traceWarnStr: traceWarnStr,
-		traceErrStr:  traceErrStr,
+// textStyle are the format strings of the text lines.
+type textStyle struct {
+	debugStr, infoStr, warnStr, errStr  string
+	traceStr, traceErrStr, traceWarnStr string
+	traceCritStr                        string
+}
+
+// withoutCaller removes the line break after the caller, which is empty with DisableCaller, so the lines
+// start by the level, or by the prefixes added to the caller (TimeFormat, Named).
+func (s textStyle) withoutCaller() textStyle {
+	for _, f := range []*string{&s.debugStr, &s.infoStr, &s.warnStr, &s.errStr, &s.traceStr} {
+		*f = strings.Replace(*f, "%s\n", "%s", 1)
+	}
+	for _, f := range []*string{&s.traceErrStr, &s.traceWarnStr, &s.traceCritStr} {
+		*f = strings.Replace(*f, "%s ", "%s", 1)
+	}
+	return s
+}
+
+func newTextStyle(colorful bool, theme *Theme) textStyle {
+	if colorful && theme != nil {
+		return theme.style()
+	}
+	if colorful {
+		return textStyle{
+			debugStr:     Cyan + "%s\n" + Reset + Cyan + "[debug] " + Reset,
+			infoStr:      Green + "%s\n" + Reset + Green + "[info] " + Reset,
+			warnStr:      BlueBold + "%s\n" + Reset + Magenta + "[warn] " + Reset,
+			errStr:       Magenta + "%s\n" + Reset + Red + "[error] " + Reset,
+			traceStr:     Green + "%s\n" + Reset + Yellow + "[%s] " + BlueBold + "[rows:%v]" + Reset + " %s",
+			traceWarnStr: Green + "%s " + Yellow + "%s\n" + Reset + RedBold + "[%s] " + Yellow + "[rows:%v]" + Magenta + " %s" + Reset,
+			traceErrStr:  RedBold + "%s " + MagentaBold + "%s\n" + Reset + Yellow + "[%s] " + BlueBold + "[rows:%v]" + Reset + " %s",
+			traceCritStr: RedBold + "%s " + RedBold + "%s\n" + Reset + RedBold + "[%s] " + Yellow + "[rows:%v]" + Red + " %s" + Reset,
+		}
+	}
+	return textStyle{
+		debugStr:     "%s\n[debug] ",
+		infoStr:      "%s\n[info] ",
+		warnStr:      "%s\n[warn] ",
+		errStr:       "%s\n[error] ",
+		traceStr:     "%s\n[%s] [rows:%v] %s",
+		traceWarnStr: "%s %s\n[%s] [rows:%v] %s",
+		traceErrStr:  "%s %s\n[%s] [rows:%v] %s",
+		traceCritStr: "%s %s\n[%s] [rows:%v] %s",
 	}
 }
 
 // customLogger have Execution so it can add functions in the logger.
 type customLogger struct {
 	Writer
-	Config
 	Execution
-	infoStr, warnStr, errStr            string
-	traceStr, traceErrStr, traceWarnStr string
+	settings          *settings
+	mode              *levelMode
+	maintenance       *maintenance
+	locks             *lockReport
+	summary           *summary
+	spans             SpanRecorder
+	traceIDs          func(ctx context.Context) (traceID, spanID string)
+	requestID         func(ctx context.Context) string
+	tenant            func(ctx context.Context) string
+	contextExtractors []ContextField
+	stats             *statsHolder
+	async             *asyncQueue
+	pool              *triggerPool
+	closers           []io.Closer
+	sampler           *sampler
+	pprofLabels       bool
+	explain           *explainer
+	name              string
+	audit             *auditLog
 }
 
 // SetSlowSqlThreshold Set the slowSqlThreshold to be shown on warns
 // is the same as the original logger .
 func (l *customLogger) SetSlowSqlThreshold(t time.Duration) {
-	l.Config.SlowThreshold = t
+	l.settings.update(func(c *Config) {
+		c.SlowThreshold = t
+	})
 }
 
 // LogMode This function set the LogMode and returns a Gorm - Interface.
-// This wil block t he edition of the Triggers.
-// The edition Should be block cause changing it isn't concurrency safe
+// The rest of the configuration is shared, the level is kept until the next Reload or SetLogLevel.
+// The triggers are shared with the returned logger, they can still be changed through l at any time.
+// Use SessionTriggers for a logger with its own triggers.
 func (l *customLogger) LogMode(level lg.LogLevel) lg.Interface {
 	newLogger := *l
-	newLogger.LogLevel = level
+	newLogger.mode = &levelMode{level: level, version: l.settings.load().version}
 
 	return &newLogger
 }
 
-// FixTriggers will set the Gorm Interface and block all Trigger functions.
+// Named returns a copy of the logger labeling its lines with name ([name] before the caller in the text format,
+// logger in the others) and GormInfos.Logger, to tell which gorm.DB issued a query. The names of a Named
+// logger are joined by a dot: logger.Named("orders").Named("repo") is orders.repo.
+//
+//	orders, err := gorm.Open(dialector, &gorm.Config{Logger: logger.Named("orders-repo")})
+func (l *customLogger) Named(name string) CInterface {
+	named := *l
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	named.name = name
+	return &named
+}
+
+// FixTriggers returns the logger as a Gorm Interface, hiding the Trigger functions.
 func (l *customLogger) FixTriggers() lg.Interface {
 	return l
 }
 
 // AlwaysTrigger will trigger during all sql that use this logger.
 func (l *customLogger) AlwaysTrigger(f func(g GormInfos)) CInterface {
-	l.always = f
+	l.setTrigger(alwaysTrigger(alwaysName, f))
 	return l
 }
 
 // SlowTrigger will trigger if the query took more than the duration
 func (l *customLogger) SlowTrigger(f func(g GormInfos), duration time.Duration) CInterface {
-	l.warns = f
-	l.slowSqlTrigger = duration
+	l.setTrigger(slowTrigger(slowName, f, duration))
 	return l
 }
 
 // ErrorTrigger will trigger if gorm presents an error.  By default this will ignore ErrRecordNotFound
 func (l *customLogger) ErrorTrigger(f func(g GormInfos)) CInterface {
-	l.errors = f
+	l.setTrigger(errorTrigger(errorName, f))
+	return l
+}
+
+// OnDuplicateKey will trigger when the query fails by a unique constraint.
+func (l *customLogger) OnDuplicateKey(f func(g GormInfos)) CInterface {
+	l.setTrigger(trigger{name: duplicateKeyName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return isDuplicateKey(e.infos.Err)
+	}})
+	return l
+}
+
+// OnConstraintViolation will trigger when the query fails by a foreign key, check or not null constraint.
+// Unique constraints are reported by OnDuplicateKey.
+func (l *customLogger) OnConstraintViolation(f func(g GormInfos)) CInterface {
+	l.setTrigger(trigger{name: constraintViolationName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return isConstraintViolation(e.infos.Err)
+	}})
+	return l
+}
+
+// CanceledTrigger will trigger when the query fails because its context was canceled or its deadline exceeded,
+// GormInfos.Elapsed is how long it ran before, to measure the work wasted on abandoned requests.
+// The ErrorTrigger is still called for them.
+func (l *customLogger) CanceledTrigger(f func(g GormInfos)) CInterface {
+	l.setTrigger(trigger{name: canceledName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return isCanceled(e.ctx, e.infos.Err)
+	}})
+	return l
+}
+
+// DeadlineTrigger will trigger when the query succeeded but consumed at least the ratio (ex: 0.9) of the time
+// its context had left when it started. It is also logged as a warning: the queries almost timed out are the
+// ones failing under load.
+func (l *customLogger) DeadlineTrigger(f func(g GormInfos), ratio float64) CInterface {
+	if f == nil {
+		l.removeTrigger(deadlineName)
+		return l
+	}
+
+	l.setTrigger(trigger{name: deadlineName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return e.infos.Err == nil && deadlineConsumed(e.infos) >= ratio
+	}, line: func(l customLogger, g GormInfos) {
+		l.printf(context.Background(), lg.Warn, LevelWarn, g.Location, "NEAR DEADLINE: %.0f%% of the context deadline consumed",
+			[]interface{}{deadlineConsumed(g) * 100, "sql", g.Sql})
+	}})
+	return l
+}
+
+// deadlineConsumed returns the ratio of the time left by the context deadline at the begin of the query
+// that it took, 0 without deadline.
+func deadlineConsumed(g GormInfos) float64 {
+	if g.Context == nil {
+		return 0
+	}
+	deadline, ok := g.Context.Deadline()
+	if !ok {
+		return 0
+	}
+	budget := deadline.Sub(g.Begin)
+	if budget <= 0 {
+		return 1
+	}
+	return float64(g.Elapsed) / float64(budget)
+}
+
+// OnErrorClass will trigger when the query fails with an error of the class (see GormInfos.ErrClass),
+// ex: to page only on the infrastructure failures. There is one trigger per class.
+func (l *customLogger) OnErrorClass(class string, f func(g GormInfos)) CInterface {
+	l.setTrigger(trigger{name: errorClassName + class, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return e.infos.ErrClass == class
+	}})
+	return l
+}
+
+// OnDeadlock will trigger when the query fails by a deadlock, see OnErrorClass.
+func (l *customLogger) OnDeadlock(f func(g GormInfos)) CInterface {
+	return l.OnErrorClass(ErrClassDeadlock, f)
+}
+
+// OnTimeout will trigger when the query fails by a statement, lock wait or context deadline timeout, see OnErrorClass.
+func (l *customLogger) OnTimeout(f func(g GormInfos)) CInterface {
+	return l.OnErrorClass(ErrClassTimeout, f)
+}
+
+// OnConnectionError will trigger when the query fails by a broken, refused or closed connection, see OnErrorClass.
+func (l *customLogger) OnConnectionError(f func(g GormInfos)) CInterface {
+	return l.OnErrorClass(ErrClassConnection, f)
+}
+
+// CriticalTrigger will trigger when the query took more than the CriticalThreshold of the Config,
+// the second tier of slow queries, ex: SlowTrigger to a channel at 200ms and CriticalTrigger to the pager at 1s.
+func (l *customLogger) CriticalTrigger(f func(g GormInfos)) CInterface {
+	settings := l.settings
+	l.setTrigger(trigger{name: criticalName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		threshold := settings.get().CriticalThreshold
+		return threshold != 0 && e.elapsed > threshold
+	}})
+	return l
+}
+
+// ReadInWriteTxTrigger will trigger when a SELECT took more than the duration inside a transaction
+// that already wrote, holding its locks while reading. The transaction context must be marked with TxContext.
+func (l *customLogger) ReadInWriteTxTrigger(f func(g GormInfos), duration time.Duration) CInterface {
+	l.setTrigger(trigger{name: readInWriteTxName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return readInWriteTx(e.ctx, e.infos.Sql, e.elapsed, duration)
+	}})
+	return l
+}
+
+// DangerTrigger will trigger when an UPDATE or DELETE without WHERE, a TRUNCATE or a DROP goes through the logger,
+// the statement is also logged at the Error level. It is a last line of defense against accidental mass mutations.
+func (l *customLogger) DangerTrigger(f func(g GormInfos)) CInterface {
+	if f == nil {
+		l.removeTrigger(dangerName)
+		return l
+	}
+
+	l.setTrigger(trigger{name: dangerName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return dangerousStatement(e.infos.Sql) != ""
+	}, line: func(l customLogger, g GormInfos) {
+		l.printf(context.Background(), lg.Error, LevelError, g.Location, "DANGEROUS SQL: %s", []interface{}{dangerousStatement(g.Sql), "sql", g.Sql})
+	}})
+	return l
+}
+
+// RowsTrigger will trigger when the statement affected more than minRows rows, even when it is fast.
+func (l *customLogger) RowsTrigger(f func(g GormInfos), minRows int64) CInterface {
+	l.setTrigger(trigger{name: rowsName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return e.infos.AffectedRows > minRows
+	}})
+	return l
+}
+
+// NPlusOneTrigger will trigger when the same query shape (GormInfos.Fingerprint) runs more than threshold times
+// in a request, the classic N+1 of loading the associations in a loop. It is called once per query shape and request,
+// with the location of the query, which is also logged as a warning. The request context must be marked with StartRequest.
+func (l *customLogger) NPlusOneTrigger(f func(g GormInfos), threshold int) CInterface {
+	if f == nil {
+		l.removeTrigger(nPlusOneName)
+		return l
+	}
+
+	l.setTrigger(trigger{name: nPlusOneName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return e.infos.Executions == threshold+1
+	}, line: func(l customLogger, g GormInfos) {
+		l.printf(context.Background(), lg.Warn, LevelWarn, g.Location, "N+1 QUERY: more than %d executions in the request",
+			[]interface{}{threshold, "sql", g.Fingerprint})
+	}})
+	return l
+}
+
+// DuplicateQueryTrigger will trigger every time a statement identical to a previous one (same values) runs again
+// in a request, GormInfos.Repeats has the count. It spots the missing memoization and the double loads.
+// The request context must be marked with StartRequest.
+func (l *customLogger) DuplicateQueryTrigger(f func(g GormInfos)) CInterface {
+	l.setTrigger(trigger{name: duplicateQueryName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return e.infos.Repeats > 1
+	}})
+	return l
+}
+
+// LockReport returns the deadlocks and lock wait timeouts by query fingerprint, the most frequent first.
+func (l *customLogger) LockReport() []LockContention {
+	return l.locks.report()
+}
+
+// SummaryMode turns on the collection of the query latencies by fingerprint, see PrintSummary.
+// It is meant for benchmarks and load tests.
+func (l *customLogger) SummaryMode(on bool) CInterface {
+	l.summary.toggle(on)
+	return l
+}
+
+// PrintSummary writes the latencies collected in summary mode with a histogram per query fingerprint.
+func (l *customLogger) PrintSummary(w io.Writer) error {
+	return l.summary.print(w)
+}
+
+// RecordSpans records a tracing span for each query in the context given to Trace, ex: OpenTelemetry.
+func (l *customLogger) RecordSpans(r SpanRecorder) CInterface {
+	l.spans = r
+	return l
+}
+
+// TraceIDs sets how the trace and span IDs are read from the context, they are written in every line
+// (trace_id and span_id) and set in GormInfos. With OpenTelemetry:
+//
+//	TraceIDs(func(ctx context.Context) (string, string) {
+//		sc := trace.SpanContextFromContext(ctx)
+//		return sc.TraceID().String(), sc.SpanID().String()
+//	})
+func (l *customLogger) TraceIDs(f func(ctx context.Context) (traceID, spanID string)) CInterface {
+	l.traceIDs = f
 	return l
 }
 
 // ConsiderNotFound  if true will consider ErrRecordNotFound as an error to invoke the ErrorsTrigger
 func (l *customLogger) ConsiderNotFound(b bool) CInterface {
-	l.considerRecordNotFoundError = b
+	l.registry.update(func(s *triggerState) {
+		s.considerRecordNotFoundError = b
+	})
+	return l
+}
+
+// MaintenanceWindow declares a period where only the AlwaysTrigger is invoked.
+// The suppressed invocations are still counted, see SuppressedTriggers.
+func (l *customLogger) MaintenanceWindow(start, end time.Time) CInterface {
+	l.maintenance.add(MaintenanceWindow{Start: start, End: end})
 	return l
 }
 
+// Maintenance turns the maintenance mode on or off regardless of the declared windows.
+func (l *customLogger) Maintenance(on bool) CInterface {
+	l.maintenance.toggle(on)
+	return l
+}
+
+// SuppressedTriggers returns how many trigger calls were skipped during maintenance.
+func (l *customLogger) SuppressedTriggers() int64 {
+	return l.maintenance.count()
+}
+
+// Schedule changes the log level by the time of day.
+// Inside the ranges of the schedule the scheduled level takes precedence over the LogLevel and the levels
+// of LogMode, but not over the level given to SetLogLevel: calling Schedule ends it.
+// The schedule is shared with the loggers derived with LogMode and can be changed while gorm is using the logger.
+func (l *customLogger) Schedule(s LevelSchedule) CInterface {
+	s.Ranges = append([]LevelRange(nil), s.Ranges...)
+	l.settings.change(func(snapshot *settingsSnapshot) {
+		snapshot.schedule = &s
+		snapshot.levelSet = false
+	})
+	return l
+}
+
+// level returns the LogLevel in effect now, in this order: the schedule (not after SetLogLevel),
+// the level of LogMode (discarded by SetLogLevel and Reload) and the LogLevel.
+func (l customLogger) level() lg.LogLevel {
+	current := l.settings.load()
+	if current.schedule != nil && !current.levelSet {
+		if level, ok := current.schedule.level(time.Now()); ok {
+			return level
+		}
+	}
+	if l.mode != nil && l.mode.version == current.version {
+		return l.mode.level
+	}
+	return current.config.LogLevel
+}
+
 /*******************************
 *	COPY OF THE DEFAULT LOGGER *
 *******************************/
 
 // Info print info
 func (l customLogger) Info(ctx context.Context, msg string, data ...interface{}) {
-	if l.LogLevel >= lg.Info {
-		l.Printf(l.infoStr+msg, append([]interface{}{utils.FileWithLineNum()}, data...)...)
+	if noop {
+		return
+	}
+	if l.levelFor(ctx) < lg.Info {
+		return
+	}
+
+	location := ""
+	if !l.config().DisableCaller {
+		location = l.skipCaller(utils.FileWithLineNum())
 	}
+	l.printf(ctx, lg.Info, LevelInfo, location, msg, data)
 }
 
 // Warn print warn messages
 func (l customLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
-	if l.LogLevel >= lg.Warn {
-		l.Printf(l.warnStr+msg, append([]interface{}{utils.FileWithLineNum()}, data...)...)
+	if noop {
+		return
+	}
+	if l.levelFor(ctx) < lg.Warn {
+		return
+	}
+
+	location := ""
+	if !l.config().DisableCaller {
+		location = l.skipCaller(utils.FileWithLineNum())
 	}
+	l.printf(ctx, lg.Warn, LevelWarn, location, msg, data)
 }
 
 // Error print error messages
 func (l customLogger) Error(ctx context.Context, msg string, data ...interface{}) {
-	if l.LogLevel >= lg.Error {
-		l.Printf(l.errStr+msg, append([]interface{}{utils.FileWithLineNum()}, data...)...)
+	if noop {
+		return
+	}
+	if l.levelFor(ctx) < lg.Error {
+		return
 	}
+
+	location := ""
+	if !l.config().DisableCaller {
+		location = l.skipCaller(utils.FileWithLineNum())
+	}
+	l.printf(ctx, lg.Error, LevelError, location, msg, data)
 }
 
 /* END OF THE COPY */
@@ -191,63 +702,270 @@ func (l customLogger) Error(ctx context.Context, msg string, data ...interface{}
 // AlwaysTrigger
 // SlowTrigger
 // ErrorTrigger
+// OnDuplicateKey / OnConstraintViolation
+// ReadInWriteTxTrigger
+// During a maintenance window only the AlwaysTrigger is executed.
 func (l customLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
-	sql, rows := fc()
+	if noop {
+		return
+	}
+
+	// The fast path: a query that is fully suppressed is only counted, before the caller lookup,
+	// without heap allocation.
+	snapshot := l.settings.load()
+	if l.suppressed(ctx, snapshot, time.Since(begin), err) {
+		return
+	}
+
+	location := ""
+	if !snapshot.config.DisableCaller {
+		location = l.skipCaller(utils.FileWithLineNum())
+	}
+	l.trace(ctx, location, begin, fc, err)
+}
+
+// skipSQL reports if nothing needs the statement of a successful query that isn't slow, so fc isn't called:
+// no line (the level is under Info), no trigger and none of the features reading the statements.
+// The statement building of gorm is expensive with many bind vars. Only the Stats count the query then.
+func (l customLogger) skipSQL(ctx context.Context, snapshot *settingsSnapshot, elapsed time.Duration, err error) bool {
+	if err != nil || l.levelFor(ctx) >= lg.Info || len(l.execution().triggers) > 0 {
+		return false
+	}
+	if min := snapshot.config.minSlowThreshold(); min != 0 && elapsed > min {
+		return false
+	}
+	if snapshot.filter != nil || l.spans != nil || l.summary.on() || snapshot.slowest != nil || snapshot.percentiles != nil ||
+		snapshot.recent != nil || l.audit != nil || l.pprofLabels || requestFromContext(ctx) != nil {
+		return false
+	}
+	return true
+}
+
+// suppressed reports if skipSQL skips the query, it is then only counted in the Stats.
+func (l customLogger) suppressed(ctx context.Context, snapshot *settingsSnapshot, elapsed time.Duration, err error) bool {
+	if !l.skipSQL(ctx, snapshot, elapsed, err) {
+		return false
+	}
+	tenant := ""
+	if l.tenant != nil && ctx != nil {
+		tenant = l.tenant(ctx)
+	}
+	l.stats.get().record(elapsed, false, nil, tenant)
+	return true
+}
+
+// trace is the body of Trace, location is resolved by the caller so adapters can provide their own.
+func (l customLogger) trace(ctx context.Context, location string, begin time.Time, fc func() (string, int64), err error) {
 	elapsed := time.Since(begin)
-	slowSql := elapsed > l.SlowThreshold && l.SlowThreshold != 0
+	snapshot := l.settings.load()
+	config, redaction := snapshot.config, snapshot.redaction
+	if l.suppressed(ctx, snapshot, elapsed, err) {
+		return
+	}
+
+	sql, rows := fc()
+	// the ignored statements are still audited, the filters are for the operational logs.
+	ignored := snapshot.filter.ignored(sql)
+	if ignored && l.audit == nil {
+		return
+	}
+	raw := sql
+	sql = sanitize(sql, config, redaction)
+	operation := sqlOperation(sql)
+	tables := sqlTables(sql)
+	slowThreshold := config.slowThreshold(operation, tables)
+	critical := elapsed > config.CriticalThreshold && config.CriticalThreshold != 0
+	slowSql := elapsed > slowThreshold && slowThreshold != 0 || critical
 
 	g := GormInfos{
-		Location:      utils.FileWithLineNum(),
+		Location:      location,
 		AffectedRows:  rows,
 		QueryDuration: float64(elapsed.Nanoseconds()) / 1e6,
+		Begin:         begin,
+		End:           begin.Add(elapsed),
+		Elapsed:       elapsed,
+		LogLevel:      l.levelFor(ctx),
 		Sql:           sql,
 		Err:           err,
+		ErrClass:      ErrorClass(err),
+		Fingerprint:   fingerprint(sql),
+		Operation:     operation,
+		Tables:        tables,
+		Context:       ctx,
+		Statement:     l.statement(ctx, config, redaction),
+		Logger:        l.name,
+	}
+	ids, idFields := l.contextFields(ctx)
+	g.TraceID, g.SpanID, g.RequestID, g.Tenant = ids.traceID, ids.spanID, ids.requestID, ids.tenant
+	if len(ids.fields) > 0 {
+		g.Fields = make(map[string]interface{}, len(ids.fields))
+		for _, f := range ids.fields {
+			g.Fields[f.Key] = f.Value
+		}
+	}
+	if l.audit != nil {
+		l.audit.record(g, idFields)
+	}
+	if ignored {
+		return
 	}
 
-	if l.always != nil {
-		l.always(g)
+	if l.pprofLabels {
+		defer setLabels(ctx, g)()
+	}
+	if slowSql && l.explain != nil {
+		// the plans echo the values of the statement.
+		if plan := l.explain.plan(ctx, operation); plan != "" {
+			g.Plan = sanitize(plan, config, redaction)
+		}
+	}
+	g.Lock = l.locks.observe(sql, g.Fingerprint, begin, elapsed, err)
+	if req := requestFromContext(ctx); req != nil {
+		// the identical statements are compared with their values (only their hash is kept), stripped or masked
+		// they would all be equal.
+		g.Executions, g.Repeats = req.record(g.Fingerprint, raw, elapsed, slowSql, err)
 	}
 
-	if l.slowSqlTrigger != 0 && elapsed > l.slowSqlTrigger && l.warns != nil {
-		l.warns(g)
+	stats := l.stats.get()
+	stats.record(elapsed, slowSql, err, g.Tenant)
+
+	if l.spans != nil {
+		recordSpan(l.spans, ctx, g, begin, elapsed)
 	}
 
-	if err != nil && (!errors.Is(err, ErrRecordNotFound) || l.considerRecordNotFoundError) && l.errors != nil {
-		l.errors(g)
+	if l.summary.on() {
+		l.summary.record(g.Fingerprint, elapsed)
+	}
+	if snapshot.slowest != nil {
+		snapshot.slowest.record(g)
+	}
+	if snapshot.percentiles != nil {
+		snapshot.percentiles.record(g.Fingerprint, elapsed)
 	}
+	if snapshot.recent != nil {
+		snapshot.recent.add(g)
+	}
+
+	l.runTriggers(ctx, elapsed, g)
 
-	if l.LogLevel <= lg.Silent {
+	level := g.LogLevel
+	if level <= lg.Silent {
 		return
 	}
 
+	e := Entry{
+		Context:  ctx,
+		Time:     time.Now(),
+		Location: location,
+		Trace:    true,
+		Duration: elapsed,
+		Rows:     rows,
+		SQL:      sql,
+		Err:      err,
+		Fields:   idFields,
+	}
+	if config.Templates != nil {
+		// a copy, so g doesn't escape to the heap without templates.
+		infos := g
+		e.infos = &infos
+	}
+	if g.Statement != nil && g.Statement.TxID != "" {
+		e.Fields = append(e.Fields, Field{Key: "tx", Value: g.Statement.TxID})
+	}
+	if g.Plan != "" {
+		e.Fields = append(e.Fields, Field{Key: "plan", Value: g.Plan})
+	}
+
 	switch {
-	case err != nil && l.LogLevel >= lg.Error && (!errors.Is(err, ErrRecordNotFound) || !l.IgnoreRecordNotFoundError):
-		if rows == -1 {
-			l.Printf(l.traceErrStr, utils.FileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, "-", sql)
-		} else {
-			l.Printf(l.traceErrStr, utils.FileWithLineNum(), err, float64(elapsed.Nanoseconds())/1e6, rows, sql)
+	case err != nil && level >= lg.Error && (!errors.Is(err, ErrRecordNotFound) || !config.IgnoreRecordNotFoundError):
+		e.Level = LevelError
+	case critical && level >= lg.Warn:
+		e.Level = LevelWarn
+		e.Critical = true
+		e.Message = fmt.Sprintf("CRITICAL SLOW SQL >= %v", config.CriticalThreshold)
+	case slowSql && level >= lg.Warn:
+		e.Level = LevelWarn
+		e.Message = fmt.Sprintf("SLOW SQL >= %v", slowThreshold)
+	case level == lg.Info && !(snapshot.healthChecks && healthCheck.MatchString(raw)) && l.sampler.keep(config.SampleInfo):
+		e.Level = LevelInfo
+	default:
+		return
+	}
+
+	if snapshot.dedup != nil && e.Level == LevelError {
+		write, repeats := snapshot.dedup.add(g.Fingerprint, e)
+		if repeats != nil {
+			l.write(*repeats)
 		}
-	case slowSql && l.LogLevel >= lg.Warn:
-		slowLog := fmt.Sprintf("SLOW SQL >= %v", l.SlowThreshold)
-		if rows == -1 {
-			l.Printf(l.traceWarnStr, utils.FileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, "-", sql)
-		} else {
-			l.Printf(l.traceWarnStr, utils.FileWithLineNum(), slowLog, float64(elapsed.Nanoseconds())/1e6, rows, sql)
+		if !write {
+			return
 		}
-	case l.LogLevel == lg.Info:
-		if rows == -1 {
-			l.Printf(l.traceStr, utils.FileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, "-", sql)
-		} else {
-			l.Printf(l.traceStr, utils.FileWithLineNum(), float64(elapsed.Nanoseconds())/1e6, rows, sql)
+	}
+
+	if snapshot.limiter != nil {
+		ok, suppressed := snapshot.limiter.allow(g.Fingerprint, e)
+		if suppressed > 0 {
+			l.write(Entry{
+				Context:  ctx,
+				Time:     e.Time,
+				Level:    e.Level,
+				Location: location,
+				Message:  fmt.Sprintf("suppressed %d similar entries", suppressed),
+				Fields:   []Field{{Key: "fingerprint", Value: g.Fingerprint}},
+			})
+		}
+		if !ok {
+			return
 		}
 	}
+
+	stats.written(e.Level)
+	l.write(e)
+}
+
+// sanitize applies ParameterizedQueries, the Redact rules and MaskColumns to sql, in this order.
+func sanitize(sql string, config Config, redaction redaction) string {
+	if config.ParameterizedQueries {
+		sql = stripLiterals(sql)
+	}
+	if len(redaction.rules) > 0 {
+		sql = redact(sql, redaction.rules)
+	}
+	if len(redaction.masked) > 0 {
+		sql = maskColumns(sql, redaction.masked)
+	}
+	return sql
+}
+
+// DedupErrors collapses the consecutive error lines with the same error and query fingerprint: the first one
+// is written, the next ones during window are counted and written as one "last error repeated N times" line
+// when another error comes or the window expires. A zero window turns it off.
+// It is shared with the loggers derived with LogMode and can be changed while gorm is using the logger.
+func (l *customLogger) DedupErrors(window time.Duration) CInterface {
+	var dedup *errorDedup
+	if window > 0 {
+		dedup = &errorDedup{window: window, write: func(e Entry) { l.write(e) }}
+	}
+	l.settings.change(func(s *settingsSnapshot) { s.dedup = dedup })
+	return l
+}
+
+// RateLimit writes at most n lines per query fingerprint and period, ex: 10 per minute, so a hot loop doesn't
+// flood the logs. The lines suppressed in a period are counted in a "suppressed N similar entries" line, written
+// before the next line of the fingerprint or at the end of the period.
+// A zero n removes the limit. The triggers and Stats see all the queries.
+// The limit is shared with the loggers derived with LogMode and can be changed while gorm is using the logger.
+func (l *customLogger) RateLimit(n int, period time.Duration) CInterface {
+	var limiter *fingerprintLimiter
+	if n > 0 {
+		limiter = newFingerprintLimiter(n, period, func(e Entry) { l.write(e) })
+	}
+	l.settings.change(func(s *settingsSnapshot) { s.limiter = limiter })
+	return l
 }
 
-// Execution contains the Methods to be hold
+// Execution contains the Methods to be hold.
+// They are shared with the loggers derived by LogMode and can be changed at any time.
 type Execution struct {
-	always                      func(g GormInfos)
-	warns                       func(g GormInfos)
-	slowSqlTrigger              time.Duration
-	errors                      func(f GormInfos)
-	considerRecordNotFoundError bool
+	registry *triggerRegistry
 }
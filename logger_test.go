@@ -0,0 +1,75 @@
+package cgLogger_test
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"cgLogger"
+	"cgLogger/cglogtest"
+
+	"gorm.io/gorm/logger"
+)
+
+var query = cglogtest.Query{SQL: "SELECT * FROM `users` WHERE `users`.`id` = 42 AND `users`.`deleted_at` IS NULL LIMIT 1", Rows: 1}
+
+// triggers adds the triggers of the "Triggers" benchmarks, they are executed on every query.
+func triggers(l cgLogger.CInterface) {
+	l.AlwaysTrigger(func(g cgLogger.GormInfos) {}).
+		SlowTrigger(func(g cgLogger.GormInfos) {}, time.Second).
+		ErrorTrigger(func(g cgLogger.GormInfos) {})
+}
+
+// TestTraceSuppressedAllocs checks that a query filtered out by the level, without triggers, doesn't allocate.
+func TestTraceSuppressedAllocs(t *testing.T) {
+	for _, level := range []logger.LogLevel{logger.Silent, logger.Error, logger.Warn} {
+		l := cgLogger.New(log.New(ioutil.Discard, "\r\n", log.LstdFlags), cgLogger.Config{LogLevel: level, SlowThreshold: time.Second})
+		ctx := context.Background()
+		fc := func() (string, int64) {
+			return query.SQL, query.Rows
+		}
+		if allocs := testing.AllocsPerRun(100, func() {
+			l.Trace(ctx, time.Now(), fc, nil)
+		}); allocs != 0 {
+			t.Errorf("level %d: Trace allocates %v times, want 0", level, allocs)
+		}
+	}
+}
+
+func BenchmarkTraceSilent(b *testing.B) {
+	cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Silent, SlowThreshold: time.Second}, nil, query)
+}
+
+func BenchmarkTraceSilentTriggers(b *testing.B) {
+	cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Silent, SlowThreshold: time.Second}, triggers, query)
+}
+
+func BenchmarkTraceWarn(b *testing.B) {
+	cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Warn, SlowThreshold: time.Second}, nil, query)
+}
+
+func BenchmarkTraceWarnTriggers(b *testing.B) {
+	cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Warn, SlowThreshold: time.Second}, triggers, query)
+}
+
+func BenchmarkTraceInfo(b *testing.B) {
+	cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Info}, nil, query)
+}
+
+func BenchmarkTraceInfoTriggers(b *testing.B) {
+	cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Info}, triggers, query)
+}
+
+func BenchmarkTraceInfoColorful(b *testing.B) {
+	cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Info, Colorful: true}, nil, query)
+}
+
+func BenchmarkTraceInfoJSON(b *testing.B) {
+	cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Info, Format: cgLogger.JSONFormat}, nil, query)
+}
+
+func BenchmarkTraceInfoLogfmt(b *testing.B) {
+	cglogtest.Benchmark(b, cgLogger.Config{LogLevel: logger.Info, Format: cgLogger.LogfmtFormat}, nil, query)
+}
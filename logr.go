@@ -0,0 +1,68 @@
+package cgLogger
+
+import "fmt"
+
+// LogrSink is implemented by logr.LogSink, it is declared here so the package doesn't depend on logr.
+type LogrSink interface {
+	Enabled(level int) bool
+	Info(level int, msg string, keysAndValues ...interface{})
+	Error(err error, msg string, keysAndValues ...interface{})
+}
+
+// LogrWriter writes to a logr sink using V-levels and key/value pairs:
+// warnings are V(0), info V(1) and debug V(2), errors go to Error with the query error.
+//
+//	logger := cgLogger.New(cgLogger.NewLogrWriter(log.GetSink()), config)
+type LogrWriter struct {
+	Sink LogrSink
+	// V-levels of the warn, info and debug lines.
+	WarnLevel  int
+	InfoLevel  int
+	DebugLevel int
+}
+
+// NewLogrWriter returns a Writer logging to sink with the default V-levels.
+func NewLogrWriter(sink LogrSink) LogrWriter {
+	return LogrWriter{Sink: sink, WarnLevel: 0, InfoLevel: 1, DebugLevel: 2}
+}
+
+// Printf logs the lines that don't come as entries at the info V-level.
+func (w LogrWriter) Printf(format string, data ...interface{}) {
+	if w.Sink.Enabled(w.InfoLevel) {
+		w.Sink.Info(w.InfoLevel, fmt.Sprintf(format, data...))
+	}
+}
+
+// WriteEntry logs e at the matching V-level.
+func (w LogrWriter) WriteEntry(e Entry) {
+	if e.Level == LevelError {
+		kv := e.keyValues()
+		if e.Err != nil {
+			// the error is given apart, not as a key/value pair.
+			kv = withoutKey(kv, "error")
+		}
+		w.Sink.Error(e.Err, e.message(), kv...)
+		return
+	}
+
+	level := w.InfoLevel
+	switch e.Level {
+	case LevelWarn:
+		level = w.WarnLevel
+	case LevelDebug:
+		level = w.DebugLevel
+	}
+	if w.Sink.Enabled(level) {
+		w.Sink.Info(level, e.message(), e.keyValues()...)
+	}
+}
+
+func withoutKey(kv []interface{}, key string) []interface{} {
+	out := kv[:0:0]
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i] != key {
+			out = append(out, kv[i], kv[i+1])
+		}
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package cgLogger
+
+import "fmt"
+
+// LogrusEntry is implemented by *logrus.Entry, it is declared here so the package doesn't depend on logrus.
+type LogrusEntry interface {
+	Debug(args ...interface{})
+	Info(args ...interface{})
+	Warn(args ...interface{})
+	Error(args ...interface{})
+}
+
+// LogrusWriter writes to logrus at the level of each line, the fields of the line
+// (caller, duration, rows, sql, error and the Info/Warn/Error fields) become logrus Fields.
+//
+//	logger := cgLogger.New(cgLogger.NewLogrusWriter(func(f map[string]interface{}) cgLogger.LogrusEntry {
+//		return logrusLogger.WithFields(f)
+//	}), config)
+type LogrusWriter struct {
+	// WithFields is the WithFields of a *logrus.Logger or *logrus.Entry.
+	WithFields func(fields map[string]interface{}) LogrusEntry
+}
+
+// NewLogrusWriter returns a Writer logging to the entries created by withFields.
+func NewLogrusWriter(withFields func(fields map[string]interface{}) LogrusEntry) LogrusWriter {
+	return LogrusWriter{WithFields: withFields}
+}
+
+// Printf logs the lines that don't come as entries at the Info level.
+func (w LogrusWriter) Printf(format string, data ...interface{}) {
+	w.WithFields(nil).Info(fmt.Sprintf(format, data...))
+}
+
+// WriteEntry logs e at the matching logrus level.
+func (w LogrusWriter) WriteEntry(e Entry) {
+	kv := e.keyValues()
+	fields := make(map[string]interface{}, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		fields[fmt.Sprint(kv[i])] = kv[i+1]
+	}
+
+	entry := w.WithFields(fields)
+	switch e.Level {
+	case LevelDebug:
+		entry.Debug(e.message())
+	case LevelWarn:
+		entry.Warn(e.message())
+	case LevelError:
+		entry.Error(e.message())
+	default:
+		entry.Info(e.message())
+	}
+}
@@ -0,0 +1,78 @@
+package cgLogger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaintenanceWindow is a time range during which the alerting triggers
+// (every trigger but the AlwaysTrigger) are suppressed.
+type MaintenanceWindow struct {
+	Start time.Time
+	End   time.Time
+}
+
+// contains reports if t is inside the window. End is exclusive.
+func (w MaintenanceWindow) contains(t time.Time) bool {
+	return !t.Before(w.Start) && t.Before(w.End)
+}
+
+// maintenance holds the declared windows and the manual toggle.
+// It is shared between the loggers derived with LogMode, so a window declared
+// on the original logger is respected by the one handed to gorm.
+type maintenance struct {
+	mu         sync.RWMutex
+	windows    []MaintenanceWindow
+	enabled    int32
+	suppressed int64
+}
+
+// add stores a new window dropping the ones that already ended.
+func (m *maintenance) add(w MaintenanceWindow) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	windows := m.windows[:0]
+	for _, old := range m.windows {
+		if old.End.After(now) {
+			windows = append(windows, old)
+		}
+	}
+	m.windows = append(windows, w)
+}
+
+// active reports if the alerting triggers must be suppressed at t.
+func (m *maintenance) active(t time.Time) bool {
+	if atomic.LoadInt32(&m.enabled) == 1 {
+		return true
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, w := range m.windows {
+		if w.contains(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *maintenance) toggle(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&m.enabled, v)
+}
+
+func (m *maintenance) suppress() {
+	atomic.AddInt64(&m.suppressed, 1)
+}
+
+func (m *maintenance) count() int64 {
+	return atomic.LoadInt64(&m.suppressed)
+}
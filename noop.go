@@ -0,0 +1,8 @@
+//go:build cglogger_noop
+// +build cglogger_noop
+
+package cgLogger
+
+// noop is set by the cglogger_noop build tag.
+// With it Info, Warn, Error and Trace compile down to an empty function, triggers included.
+const noop = true
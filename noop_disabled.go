@@ -0,0 +1,7 @@
+//go:build !cglogger_noop
+// +build !cglogger_noop
+
+package cgLogger
+
+// noop is set by the cglogger_noop build tag, see noop.go.
+const noop = false
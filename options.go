@@ -0,0 +1,244 @@
+package cgLogger
+
+import (
+	"time"
+
+	lg "gorm.io/gorm/logger"
+)
+
+// Option configures a logger built by NewWithOptions.
+type Option func(o *options)
+
+type options struct {
+	config Config
+	setup  []func(l CInterface) error
+}
+
+// NewWithOptions builds a logger from the configuration of Default (Warn, 200ms, colorful) changed by opts,
+// a nil writer writes to stdout as Default does:
+//
+//	logger := cgLogger.NewWithOptions(nil,
+//		cgLogger.WithLogLevel(logger.Info),
+//		cgLogger.WithSlowThreshold(time.Second),
+//		cgLogger.WithColor(false),
+//		cgLogger.WithTrigger("metrics", metrics),
+//	)
+//
+// The sinks of a FileConfig that fail to be created are reported in an error line, NewFromConfigFile returns the error.
+func NewWithOptions(writer Writer, opts ...Option) CInterface {
+	l, errs := newWithOptions(writer, opts)
+	for _, err := range errs {
+		l.Errorf("%v", err)
+	}
+	return l
+}
+
+// newWithOptions builds the logger, the setups that failed are skipped and their errors returned.
+func newWithOptions(writer Writer, opts []Option) (CInterface, []error) {
+	o := options{config: defaultConfig()}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if writer == nil {
+		writer = defaultWriter()
+	}
+
+	l := New(writer, o.config)
+	var errs []error
+	for _, f := range o.setup {
+		if err := f(l); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return l, errs
+}
+
+// WithConfig replaces the whole configuration, the next options change it.
+func WithConfig(config Config) Option {
+	return func(o *options) {
+		o.config = config
+	}
+}
+
+// WithSlowThreshold sets the duration from which a query is logged as SLOW SQL, 0 disables it.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.config.SlowThreshold = d
+	}
+}
+
+// WithOperationThreshold overrides the SlowThreshold for an operation, see Config.OperationThresholds:
+//
+//	WithOperationThreshold(cgLogger.OperationInsert, 5*time.Second)
+func WithOperationThreshold(operation string, d time.Duration) Option {
+	return func(o *options) {
+		thresholds := make(map[string]time.Duration, len(o.config.OperationThresholds)+1)
+		for op, t := range o.config.OperationThresholds {
+			thresholds[op] = t
+		}
+		thresholds[operation] = d
+		o.config.OperationThresholds = thresholds
+	}
+}
+
+// WithTableThreshold overrides the SlowThreshold for a table, see Config.TableThresholds:
+//
+//	WithTableThreshold("monthly_report", 2*time.Second)
+func WithTableThreshold(table string, d time.Duration) Option {
+	return func(o *options) {
+		thresholds := make(map[string]time.Duration, len(o.config.TableThresholds)+1)
+		for t, threshold := range o.config.TableThresholds {
+			thresholds[t] = threshold
+		}
+		thresholds[table] = d
+		o.config.TableThresholds = thresholds
+	}
+}
+
+// WithCriticalThreshold sets the second tier of slow queries, see Config.CriticalThreshold.
+func WithCriticalThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.config.CriticalThreshold = d
+	}
+}
+
+// WithColor turns the colors of the text format on or off.
+func WithColor(on bool) Option {
+	return func(o *options) {
+		o.config.Colorful = on
+	}
+}
+
+// WithLogLevel sets the LogLevel.
+func WithLogLevel(level lg.LogLevel) Option {
+	return func(o *options) {
+		o.config.LogLevel = level
+	}
+}
+
+// WithFormat sets the format of the lines.
+func WithFormat(f Format) Option {
+	return func(o *options) {
+		o.config.Format = f
+	}
+}
+
+// WithIgnoreRecordNotFoundError doesn't log the ErrRecordNotFound errors.
+func WithIgnoreRecordNotFoundError(ignore bool) Option {
+	return func(o *options) {
+		o.config.IgnoreRecordNotFoundError = ignore
+	}
+}
+
+// WithParameterizedQueries logs the statements without their values, see Config.ParameterizedQueries.
+func WithParameterizedQueries(on bool) Option {
+	return func(o *options) {
+		o.config.ParameterizedQueries = on
+	}
+}
+
+// WithSampleInfo writes 1 of every n successful Info traces, see Config.SampleInfo.
+func WithSampleInfo(n int) Option {
+	return func(o *options) {
+		o.config.SampleInfo = n
+	}
+}
+
+// WithPrettySQL breaks the statements in several indented lines, see Config.PrettySQL.
+func WithPrettySQL(on bool) Option {
+	return func(o *options) {
+		o.config.PrettySQL = on
+	}
+}
+
+// WithTimeFormat writes the time of the lines with layout, see Config.TimeFormat:
+//
+//	WithTimeFormat("2006-01-02 15:04:05.000")
+func WithTimeFormat(layout string) Option {
+	return func(o *options) {
+		o.config.TimeFormat = layout
+	}
+}
+
+// WithUTC writes the times of the lines in UTC.
+func WithUTC(on bool) Option {
+	return func(o *options) {
+		o.config.UTC = on
+	}
+}
+
+// WithDurationFormat sets how the duration of the traces is written, see DurationFormat.
+func WithDurationFormat(f DurationFormat) Option {
+	return func(o *options) {
+		o.config.DurationFormat = f
+	}
+}
+
+// WithCallerSkip skips n more frames when resolving the caller, see Config.CallerSkip.
+func WithCallerSkip(n int) Option {
+	return func(o *options) {
+		o.config.CallerSkip = n
+	}
+}
+
+// WithCallerSkipPrefixes skips the frames of the functions starting with the prefixes when resolving the caller,
+// see Config.CallerSkipPrefixes:
+//
+//	WithCallerSkipPrefixes("github.com/acme/orders/repository.")
+func WithCallerSkipPrefixes(prefixes ...string) Option {
+	return func(o *options) {
+		o.config.CallerSkipPrefixes = append(append([]string(nil), o.config.CallerSkipPrefixes...), prefixes...)
+	}
+}
+
+// WithDisableCaller doesn't resolve the caller of the lines, see Config.DisableCaller.
+func WithDisableCaller(on bool) Option {
+	return func(o *options) {
+		o.config.DisableCaller = on
+	}
+}
+
+// WithTemplates replaces the layouts of the text format, see Templates.
+func WithTemplates(t Templates) Option {
+	return func(o *options) {
+		o.config.Templates = &t
+	}
+}
+
+// WithTheme sets the colors of the text lines, ex: WithTheme(cgLogger.LightTheme). See Theme.
+func WithTheme(t Theme) Option {
+	return func(o *options) {
+		o.config.Theme = &t
+	}
+}
+
+// WithTrigger adds a trigger called for every query, see AddAlwaysTrigger.
+func WithTrigger(name string, f func(g GormInfos)) Option {
+	return withSetup(func(l CInterface) { l.AddAlwaysTrigger(name, f) })
+}
+
+// WithSlowTrigger adds a trigger called when the query took more than the duration, see AddSlowTrigger.
+func WithSlowTrigger(name string, f func(g GormInfos), duration time.Duration) Option {
+	return withSetup(func(l CInterface) { l.AddSlowTrigger(name, f, duration) })
+}
+
+// WithErrorTrigger adds a trigger called when the query fails, see AddErrorTrigger.
+func WithErrorTrigger(name string, f func(g GormInfos)) Option {
+	return withSetup(func(l CInterface) { l.AddErrorTrigger(name, f) })
+}
+
+// withSetup registers f to be applied to the logger once it is built.
+func withSetup(f func(l CInterface)) Option {
+	return withFallibleSetup(func(l CInterface) error {
+		f(l)
+		return nil
+	})
+}
+
+// withFallibleSetup registers f to be applied to the logger once it is built, its error is returned
+// by NewFromConfigFile.
+func withFallibleSetup(f func(l CInterface) error) Option {
+	return func(o *options) {
+		o.setup = append(o.setup, f)
+	}
+}
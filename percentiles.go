@@ -0,0 +1,160 @@
+package cgLogger
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// histogramGrowth is the ratio between the bounds of consecutive buckets of the streaming histograms,
+// the percentiles are within 5% of the real durations.
+const histogramGrowth = 1.1
+
+// maxPercentileFingerprints bounds the fingerprints tracked by TrackPercentiles, the new ones are ignored.
+const maxPercentileFingerprints = 10000
+
+// QueryPercentiles are the latency percentiles of a fingerprint, returned by Percentiles.
+type QueryPercentiles struct {
+	Fingerprint string
+	Count       int64
+	P50         time.Duration
+	P95         time.Duration
+	P99         time.Duration
+	Max         time.Duration
+}
+
+// MarshalJSON writes the durations in milliseconds.
+func (p QueryPercentiles) MarshalJSON() ([]byte, error) {
+	ms := func(d time.Duration) float64 { return float64(d.Nanoseconds()) / 1e6 }
+	return json.Marshal(struct {
+		Fingerprint string  `json:"fingerprint"`
+		Count       int64   `json:"count"`
+		P50         float64 `json:"p50_ms"`
+		P95         float64 `json:"p95_ms"`
+		P99         float64 `json:"p99_ms"`
+		Max         float64 `json:"max_ms"`
+	}{p.Fingerprint, p.Count, ms(p.P50), ms(p.P95), ms(p.P99), ms(p.Max)})
+}
+
+// streamingHistogram counts the durations in exponential buckets, its size doesn't grow with the executions.
+type streamingHistogram struct {
+	counts map[int]int64
+	count  int64
+	max    time.Duration
+}
+
+func (h *streamingHistogram) add(d time.Duration) {
+	i := 0
+	if d > time.Microsecond {
+		i = int(math.Log(float64(d)/float64(time.Microsecond)) / math.Log(histogramGrowth))
+	}
+	h.counts[i]++
+	h.count++
+	if d > h.max {
+		h.max = d
+	}
+}
+
+// quantile returns the middle of the bucket holding the q quantile, capped by the max.
+func (h *streamingHistogram) quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	indexes := make([]int, 0, len(h.counts))
+	for i := range h.counts {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	rank := int64(math.Ceil(q * float64(h.count)))
+	var seen int64
+	for _, i := range indexes {
+		seen += h.counts[i]
+		if seen >= rank {
+			d := time.Duration(float64(time.Microsecond) * math.Pow(histogramGrowth, float64(i)+0.5))
+			if d > h.max {
+				d = h.max
+			}
+			return d
+		}
+	}
+	return h.max
+}
+
+// percentileTracker keeps a histogram per fingerprint, it is shared by the loggers derived with LogMode.
+type percentileTracker struct {
+	mu      sync.Mutex
+	queries map[string]*streamingHistogram
+}
+
+func (t *percentileTracker) record(fp string, d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	h := t.queries[fp]
+	if h == nil {
+		if len(t.queries) >= maxPercentileFingerprints {
+			return
+		}
+		h = &streamingHistogram{counts: map[int]int64{}}
+		t.queries[fp] = h
+	}
+	h.add(d)
+}
+
+func (t *percentileTracker) snapshot() []QueryPercentiles {
+	t.mu.Lock()
+	out := make([]QueryPercentiles, 0, len(t.queries))
+	for fp, h := range t.queries {
+		out = append(out, QueryPercentiles{
+			Fingerprint: fp,
+			Count:       h.count,
+			P50:         h.quantile(0.50),
+			P95:         h.quantile(0.95),
+			P99:         h.quantile(0.99),
+			Max:         h.max,
+		})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].P99 != out[j].P99 {
+			return out[i].P99 > out[j].P99
+		}
+		return out[i].Fingerprint < out[j].Fingerprint
+	})
+	return out
+}
+
+// TrackPercentiles turns on the latency histograms by query fingerprint, see Percentiles.
+// Turning it off forgets them. It can be changed while gorm is using the logger.
+func (l *customLogger) TrackPercentiles(on bool) CInterface {
+	var percentiles *percentileTracker
+	if on {
+		percentiles = &percentileTracker{queries: map[string]*streamingHistogram{}}
+	}
+	l.settings.change(func(s *settingsSnapshot) { s.percentiles = percentiles })
+	return l
+}
+
+// Percentiles returns the p50, p95 and p99 durations by query fingerprint, the highest p99 first.
+// It is empty without TrackPercentiles.
+func (l *customLogger) Percentiles() []QueryPercentiles {
+	percentiles := l.settings.load().percentiles
+	if percentiles == nil {
+		return nil
+	}
+	return percentiles.snapshot()
+}
+
+// WritePercentilesJSON writes the Percentiles as a JSON array, the durations in milliseconds.
+func (l *customLogger) WritePercentilesJSON(w io.Writer) error {
+	p := l.Percentiles()
+	if p == nil {
+		p = []QueryPercentiles{}
+	}
+	return json.NewEncoder(w).Encode(p)
+}
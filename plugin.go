@@ -0,0 +1,278 @@
+package cgLogger
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	lg "gorm.io/gorm/logger"
+)
+
+// StatementInfo is the gorm Statement of a query, captured by the Plugin.
+type StatementInfo struct {
+	// Kind is the gorm operation: create, query, update, delete, row or raw.
+	Kind  string `json:"kind"`
+	Table string `json:"table,omitempty"`
+	// Model is the type of the model, ex: models.User.
+	Model string `json:"model,omitempty"`
+	// Clauses are the names of the clauses of the statement, ex: WHERE, ORDER BY, LIMIT.
+	Clauses []string `json:"clauses,omitempty"`
+	// Vars are the values bound to the statement, nil with ParameterizedQueries, Redact or MaskColumns.
+	Vars []interface{} `json:"vars,omitempty"`
+	// TxID is the id of the transaction running the statement, set with Plugin.Transactions.
+	TxID string `json:"tx_id,omitempty"`
+
+	// sql is the statement with its placeholders, run with the Vars by ExplainSlow.
+	sql string
+}
+
+type statementKey struct{}
+
+// statementHolder is the StatementInfo of the last statement run with a context, so the contexts
+// of the sessions reused for several statements aren't wrapped again and again.
+type statementHolder struct {
+	info *StatementInfo
+}
+
+// Plugin registers gorm callbacks capturing the Statement of each query, given to the triggers as
+// GormInfos.Statement:
+//
+//	db.Use(cgLogger.Plugin{})
+type Plugin struct {
+	// Transactions logs the Begin, Commit and Rollback of the transactions with their duration and number
+	// of statements, the queries inside a transaction get its id (tx field). A Commit or Rollback after
+	// more than the SlowThreshold is logged as SLOW TRANSACTION.
+	// The ConnPool of the db is wrapped, db.DB() still returns the *sql.DB. db.Logger must be a cgLogger.
+	Transactions bool
+}
+
+var errPluginLogger = errors.New("cgLogger: Plugin.Transactions needs db.Logger to be a cgLogger")
+
+// Name implements gorm.Plugin.
+func (Plugin) Name() string {
+	return "cglogger"
+}
+
+// Initialize implements gorm.Plugin, the callbacks run after the other ones, before the end of the
+// transaction of the writes so the statement still has its TxID.
+func (p Plugin) Initialize(db *gorm.DB) error {
+	cb := db.Callback()
+	registrations := []struct {
+		kind     string
+		register func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", cb.Create().Before("gorm:commit_or_rollback_transaction").Register},
+		{"query", cb.Query().After("*").Register},
+		{"update", cb.Update().Before("gorm:commit_or_rollback_transaction").Register},
+		{"delete", cb.Delete().Before("gorm:commit_or_rollback_transaction").Register},
+		{"row", cb.Row().After("*").Register},
+		{"raw", cb.Raw().After("*").Register},
+	}
+	for _, r := range registrations {
+		if err := r.register("cglogger:statement", captureStatement(r.kind)); err != nil {
+			return err
+		}
+	}
+
+	if p.Transactions {
+		l, ok := db.Logger.(*customLogger)
+		if !ok {
+			return errPluginLogger
+		}
+		pool := &txPool{ConnPool: db.ConnPool, l: l}
+		db.ConnPool = pool
+		db.Statement.ConnPool = pool
+	}
+	return nil
+}
+
+// captureStatement stores the StatementInfo in the context of the statement, read by Trace.
+func captureStatement(kind string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		stmt := db.Statement
+		info := &StatementInfo{
+			Kind:  kind,
+			Table: stmt.Table,
+			Vars:  append([]interface{}(nil), stmt.Vars...),
+			sql:   stmt.SQL.String(),
+		}
+		if stmt.Model != nil {
+			t := reflect.TypeOf(stmt.Model)
+			for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+				t = t.Elem()
+			}
+			info.Model = t.String()
+		}
+		if tx, ok := stmt.ConnPool.(*loggedTx); ok {
+			info.TxID = tx.id
+		}
+		for name := range stmt.Clauses {
+			info.Clauses = append(info.Clauses, name)
+		}
+		sort.Strings(info.Clauses)
+
+		ctx := stmt.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		if h, ok := ctx.Value(statementKey{}).(*statementHolder); ok {
+			h.info = info
+			return
+		}
+		stmt.Context = context.WithValue(ctx, statementKey{}, &statementHolder{info: info})
+	}
+}
+
+// boundStatement returns the StatementInfo captured by the Plugin with its values, nil without it.
+func boundStatement(ctx context.Context) *StatementInfo {
+	if ctx == nil {
+		return nil
+	}
+	h, ok := ctx.Value(statementKey{}).(*statementHolder)
+	if !ok {
+		return nil
+	}
+	return h.info
+}
+
+// statement returns a copy of the StatementInfo captured by the Plugin, without the values when the
+// statements are logged without them.
+func (l customLogger) statement(ctx context.Context, config Config, redaction redaction) *StatementInfo {
+	bound := boundStatement(ctx)
+	if bound == nil {
+		return nil
+	}
+	info := *bound
+	if config.ParameterizedQueries || len(redaction.rules) > 0 || len(redaction.masked) > 0 {
+		info.Vars = nil
+	}
+	return &info
+}
+
+// txCallers are skipped when resolving the location of a Begin, Commit or Rollback.
+var txCallers = []string{"gorm.io/gorm.", "gorm.io/gorm/", "database/sql."}
+
+// txSeq numbers the transactions logged by the Plugin.
+var txSeq uint64
+
+// txPool is the ConnPool of a db using Plugin.Transactions, its transactions are loggedTx.
+type txPool struct {
+	gorm.ConnPool
+	l *customLogger
+}
+
+// BeginTx implements gorm.ConnPoolBeginner.
+func (p *txPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	var (
+		tx  gorm.ConnPool
+		err error
+	)
+	switch beginner := p.ConnPool.(type) {
+	case gorm.TxBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		tx, err = beginner.BeginTx(ctx, opts)
+	default:
+		return nil, gorm.ErrInvalidTransaction
+	}
+
+	location := callerOutside(txCallers...)
+	if err != nil {
+		p.l.printf(ctx, lg.Error, LevelError, location, "begin transaction failed: %v", []interface{}{err})
+		return nil, err
+	}
+
+	t := &loggedTx{
+		ConnPool: tx,
+		l:        p.l,
+		ctx:      ctx,
+		id:       strconv.FormatUint(atomic.AddUint64(&txSeq, 1), 10),
+		begin:    time.Now(),
+	}
+	p.l.printf(ctx, lg.Info, LevelInfo, location, "begin transaction", []interface{}{"tx", t.id})
+	return t, nil
+}
+
+// GetDBConn implements gorm.GetDBConnector so db.DB() returns the wrapped *sql.DB.
+func (p *txPool) GetDBConn() (*sql.DB, error) {
+	switch pool := p.ConnPool.(type) {
+	case *sql.DB:
+		return pool, nil
+	case gorm.GetDBConnector:
+		return pool.GetDBConn()
+	}
+	return nil, gorm.ErrInvalidDB
+}
+
+// loggedTx is a transaction begun by a txPool, it counts its statements.
+type loggedTx struct {
+	gorm.ConnPool
+	l          *customLogger
+	ctx        context.Context
+	id         string
+	begin      time.Time
+	statements int64
+}
+
+func (t *loggedTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	atomic.AddInt64(&t.statements, 1)
+	return t.ConnPool.ExecContext(ctx, query, args...)
+}
+
+func (t *loggedTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	atomic.AddInt64(&t.statements, 1)
+	return t.ConnPool.QueryContext(ctx, query, args...)
+}
+
+func (t *loggedTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	atomic.AddInt64(&t.statements, 1)
+	return t.ConnPool.QueryRowContext(ctx, query, args...)
+}
+
+// Commit implements gorm.TxCommitter.
+func (t *loggedTx) Commit() error {
+	committer, ok := t.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	err := committer.Commit()
+	t.end("commit", err)
+	return err
+}
+
+// Rollback implements gorm.TxCommitter.
+func (t *loggedTx) Rollback() error {
+	committer, ok := t.ConnPool.(gorm.TxCommitter)
+	if !ok {
+		return gorm.ErrInvalidTransaction
+	}
+	err := committer.Rollback()
+	t.end("rollback", err)
+	return err
+}
+
+// end logs the end of the transaction: an error at Error, a slow one or a rollback at Warn.
+func (t *loggedTx) end(action string, err error) {
+	elapsed := time.Since(t.begin)
+	location := callerOutside(txCallers...)
+	fields := []interface{}{"tx", t.id, "duration_ms", float64(elapsed.Nanoseconds()) / 1e6,
+		"statements", atomic.LoadInt64(&t.statements)}
+
+	threshold := t.l.config().SlowThreshold
+	switch {
+	case err != nil && err != sql.ErrTxDone:
+		t.l.printf(t.ctx, lg.Error, LevelError, location, action+" transaction failed: %v", append([]interface{}{err}, fields...))
+	case threshold != 0 && elapsed > threshold:
+		t.l.printf(t.ctx, lg.Warn, LevelWarn, location, "SLOW TRANSACTION >= %v, "+action, append([]interface{}{threshold}, fields...))
+	case action == "rollback":
+		t.l.printf(t.ctx, lg.Warn, LevelWarn, location, "rollback transaction", fields)
+	default:
+		t.l.printf(t.ctx, lg.Info, LevelInfo, location, "commit transaction", fields)
+	}
+}
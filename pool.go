@@ -0,0 +1,109 @@
+package cgLogger
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OverflowPolicy is what happens to a trigger call when the queue of the worker pool is full.
+type OverflowPolicy int
+
+const (
+	// DropTrigger drops the call, see DroppedTriggers.
+	DropTrigger OverflowPolicy = iota
+	// BlockTrigger makes the query wait for room in the queue.
+	BlockTrigger
+	// RunTriggerInline runs the call in the goroutine of the query.
+	RunTriggerInline
+)
+
+// triggerPool runs the trigger calls on a fixed number of goroutines.
+type triggerPool struct {
+	queue   chan func()
+	policy  OverflowPolicy
+	dropped int64
+
+	mu      sync.RWMutex
+	closed  bool
+	workers sync.WaitGroup
+}
+
+func newTriggerPool(workers, queueSize int, policy OverflowPolicy) *triggerPool {
+	if workers <= 0 {
+		workers = 1
+	}
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	p := &triggerPool{queue: make(chan func(), queueSize), policy: policy}
+	p.workers.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *triggerPool) work() {
+	defer p.workers.Done()
+	for f := range p.queue {
+		f()
+	}
+}
+
+// submit queues f applying the overflow policy, after close the calls run inline.
+func (p *triggerPool) submit(f func()) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		f()
+		return
+	}
+
+	select {
+	case p.queue <- f:
+		return
+	default:
+	}
+
+	switch p.policy {
+	case BlockTrigger:
+		p.queue <- f
+	case RunTriggerInline:
+		f()
+	default:
+		atomic.AddInt64(&p.dropped, 1)
+	}
+}
+
+// close runs the queued calls and stops the workers.
+func (p *triggerPool) close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	p.workers.Wait()
+}
+
+// TriggerWorkers runs the triggers on workers goroutines with a queue of queueSize calls, so a slow trigger
+// (ex: one doing an HTTP call) doesn't stall the queries. policy decides what happens when the queue is full.
+// Call it before LogMode, Close waits for the queued calls.
+func (l *customLogger) TriggerWorkers(workers, queueSize int, policy OverflowPolicy) CInterface {
+	if l.pool != nil {
+		l.pool.close()
+	}
+	l.pool = newTriggerPool(workers, queueSize, policy)
+	return l
+}
+
+// DroppedTriggers returns how many trigger calls were dropped because the queue of the workers was full.
+func (l *customLogger) DroppedTriggers() int64 {
+	if l.pool == nil {
+		return 0
+	}
+	return atomic.LoadInt64(&l.pool.dropped)
+}
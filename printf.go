@@ -0,0 +1,99 @@
+package cgLogger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	lg "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+// The methods in this file don't need a context so the configured writer and
+// formatting can be reused for general application logging.
+// utils.FileWithLineNum must be called directly by them so the location is the caller one.
+
+// Debugf print debug messages, shown when the level is Info as gorm has no Debug level.
+func (l customLogger) Debugf(format string, data ...interface{}) {
+	if noop {
+		return
+	}
+	if l.level() < lg.Info {
+		return
+	}
+
+	location := ""
+	if !l.config().DisableCaller {
+		location = l.skipCaller(utils.FileWithLineNum())
+	}
+	l.printf(context.Background(), lg.Info, LevelDebug, location, format, data)
+}
+
+// Infof print info
+func (l customLogger) Infof(format string, data ...interface{}) {
+	if noop {
+		return
+	}
+	if l.level() < lg.Info {
+		return
+	}
+
+	location := ""
+	if !l.config().DisableCaller {
+		location = l.skipCaller(utils.FileWithLineNum())
+	}
+	l.printf(context.Background(), lg.Info, LevelInfo, location, format, data)
+}
+
+// Warnf print warn messages
+func (l customLogger) Warnf(format string, data ...interface{}) {
+	if noop {
+		return
+	}
+	if l.level() < lg.Warn {
+		return
+	}
+
+	location := ""
+	if !l.config().DisableCaller {
+		location = l.skipCaller(utils.FileWithLineNum())
+	}
+	l.printf(context.Background(), lg.Warn, LevelWarn, location, format, data)
+}
+
+// Errorf print error messages
+func (l customLogger) Errorf(format string, data ...interface{}) {
+	if noop {
+		return
+	}
+	if l.level() < lg.Error {
+		return
+	}
+
+	location := ""
+	if !l.config().DisableCaller {
+		location = l.skipCaller(utils.FileWithLineNum())
+	}
+	l.printf(context.Background(), lg.Error, LevelError, location, format, data)
+}
+
+// printf writes the message if the current level allows min.
+// The arguments not consumed by format are taken as key value pairs, see splitArgs.
+func (l customLogger) printf(ctx context.Context, min lg.LogLevel, level, location, format string, data []interface{}) {
+	if l.levelFor(ctx) < min {
+		return
+	}
+
+	args, fields := splitArgs(format, data)
+	if _, idFields := l.contextFields(ctx); idFields != nil {
+		fields = append(idFields, fields...)
+	}
+	l.write(Entry{
+		Context:  ctx,
+		Time:     time.Now(),
+		Level:    level,
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+		Fields:   fields,
+	})
+}
@@ -0,0 +1,90 @@
+package cgLogger
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errorRateBuckets is the number of buckets of the sliding window.
+const errorRateBuckets = 10
+
+// errorRateMinQueries is the number of queries in the window from which a fraction threshold is evaluated,
+// so a single failed query doesn't make a 100% rate.
+const errorRateMinQueries = 10
+
+// errorRate counts the queries and errors of a sliding window made of buckets.
+type errorRate struct {
+	threshold float64
+	bucket    time.Duration
+
+	mu      sync.Mutex
+	buckets [errorRateBuckets]rateBucket
+	firing  bool
+}
+
+type rateBucket struct {
+	start           time.Time
+	queries, errors int
+}
+
+func newErrorRate(threshold float64, window time.Duration) *errorRate {
+	bucket := window / errorRateBuckets
+	if bucket <= 0 {
+		bucket = time.Millisecond
+	}
+	return &errorRate{threshold: threshold, bucket: bucket}
+}
+
+// add counts a query, reporting true when the window goes over the threshold.
+// It reports it once until the window is back under the threshold.
+func (r *errorRate) add(now time.Time, failed bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	start := now.Truncate(r.bucket)
+	b := &r.buckets[int(start.UnixNano()/int64(r.bucket))%errorRateBuckets]
+	if !b.start.Equal(start) {
+		*b = rateBucket{start: start}
+	}
+	b.queries++
+	if failed {
+		b.errors++
+	}
+
+	var queries, errs int
+	oldest := start.Add(-r.bucket * (errorRateBuckets - 1))
+	for _, b := range r.buckets {
+		if !b.start.Before(oldest) {
+			queries += b.queries
+			errs += b.errors
+		}
+	}
+
+	over := false
+	if r.threshold >= 1 {
+		over = float64(errs) >= r.threshold
+	} else if queries >= errorRateMinQueries {
+		over = float64(errs)/float64(queries) > r.threshold
+	}
+
+	fire := over && !r.firing
+	r.firing = over
+	return fire
+}
+
+// ErrorRateTrigger will trigger when the failed queries of the sliding window go over the threshold:
+// below 1 it is a fraction of the queries (evaluated from 10 queries in the window), from 1 a number of errors.
+// It is called once with the query that crossed the threshold, then again only after the rate went back under it.
+// ErrRecordNotFound counts as an error with ConsiderNotFound.
+//
+//	logger.ErrorRateTrigger(page, 0.05, time.Minute) // more than 5% of the queries failed in the last minute
+func (l *customLogger) ErrorRateTrigger(f func(g GormInfos), threshold float64, window time.Duration) CInterface {
+	rate := newErrorRate(threshold, window)
+	l.setTrigger(trigger{name: errorRateName, alerting: true, f: f, match: func(e triggerEvent) bool {
+		err := e.infos.Err
+		failed := err != nil && (!errors.Is(err, ErrRecordNotFound) || e.considerNotFound)
+		return rate.add(time.Now(), failed)
+	}})
+	return l
+}
@@ -0,0 +1,70 @@
+package cgLogger
+
+import (
+	"io"
+	"sync"
+)
+
+// recentRing keeps the last queries, it is shared by the loggers derived with LogMode.
+type recentRing struct {
+	mu    sync.Mutex
+	items []GormInfos
+	next  int
+	full  bool
+}
+
+func (r *recentRing) add(g GormInfos) {
+	// the contexts of the finished requests aren't retained.
+	g.Context = nil
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items[r.next] = g
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+func (r *recentRing) list() []GormInfos {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]GormInfos(nil), r.items[:r.next]...)
+	}
+	return append(append([]GormInfos(nil), r.items[r.next:]...), r.items[:r.next]...)
+}
+
+// KeepRecent keeps the last n queries in memory, see Recent and Dump. A zero n turns it off.
+// It can be changed while gorm is using the logger, calling it again forgets the queries kept.
+func (l *customLogger) KeepRecent(n int) CInterface {
+	var recent *recentRing
+	if n > 0 {
+		recent = &recentRing{items: make([]GormInfos, n)}
+	}
+	l.settings.change(func(s *settingsSnapshot) { s.recent = recent })
+	return l
+}
+
+// Recent returns the last queries kept by KeepRecent, the oldest first.
+func (l *customLogger) Recent() []GormInfos {
+	recent := l.settings.load().recent
+	if recent == nil {
+		return nil
+	}
+	return recent.list()
+}
+
+// Dump writes the Recent queries to w, one JSON object per line, ex: when a request fails
+// to see the statements that preceded the failure.
+func (l *customLogger) Dump(w io.Writer) error {
+	for _, g := range l.Recent() {
+		line, _ := g.MarshalJSON()
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
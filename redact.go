@@ -0,0 +1,190 @@
+package cgLogger
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RedactRule replaces the matches of Pattern in the statements by Replacement (regexp.ReplaceAllString syntax).
+// When Match is set only the matches it accepts are replaced.
+type RedactRule struct {
+	Name        string
+	Pattern     *regexp.Regexp
+	Replacement string
+	Match       func(s string) bool
+}
+
+// DefaultRedactRules mask the emails, the bearer tokens, JWTs and API keys, and the card numbers written in
+// groups (4-4-4-4, 4-4-4-4-3 or 4-6-5, separated by spaces or dashes, Luhn checked). The card numbers without
+// separators are not masked, they can't be told apart from the IDs and the timestamps.
+var DefaultRedactRules = []RedactRule{
+	{
+		Name:        "email",
+		Pattern:     regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+		Replacement: "***",
+	},
+	{
+		Name:        "bearer",
+		Pattern:     regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/-]+=*`),
+		Replacement: "$1 ***",
+	},
+	{
+		Name:        "jwt",
+		Pattern:     regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+		Replacement: "***",
+	},
+	{
+		Name:        "api_key",
+		Pattern:     regexp.MustCompile(`\b(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{16,}\b`),
+		Replacement: "***",
+	},
+	{
+		Name:        "card",
+		Pattern:     regexp.MustCompile(`\b(?:\d{4}[ -]\d{4}[ -]\d{4}[ -]\d{4}(?:[ -]\d{3})?|\d{4}[ -]\d{6}[ -]\d{5})\b`),
+		Replacement: "***",
+		Match:       luhn,
+	},
+}
+
+// Redact applies the rules to the statements before they are written and given to the triggers,
+// without rules DefaultRedactRules are used. The rules are applied in order.
+func (l *customLogger) Redact(rules ...RedactRule) CInterface {
+	if len(rules) == 0 {
+		rules = DefaultRedactRules
+	}
+	rules = append([]RedactRule(nil), rules...)
+	l.settings.change(func(s *settingsSnapshot) { s.redaction.rules = rules })
+	return l
+}
+
+// redact applies the rules to sql.
+func redact(sql string, rules []RedactRule) string {
+	for _, r := range rules {
+		if r.Match == nil {
+			sql = r.Pattern.ReplaceAllString(sql, r.Replacement)
+			continue
+		}
+		sql = r.Pattern.ReplaceAllStringFunc(sql, func(m string) string {
+			if !r.Match(m) {
+				return m
+			}
+			return r.Pattern.ReplaceAllString(m, r.Replacement)
+		})
+	}
+	return sql
+}
+
+// luhn reports if the digits of s (spaces and dashes are ignored) pass the Luhn checksum of the card numbers.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && sum%10 == 0
+}
+
+// MaskColumns replaces by *** the values given to the columns in INSERT ... VALUES and in col = value
+// (UPDATE ... SET, WHERE), in the lines and in GormInfos.Sql. The names are case insensitive, ex: password, ssn, email.
+func (l *customLogger) MaskColumns(columns ...string) CInterface {
+	l.settings.change(func(s *settingsSnapshot) {
+		masked := make(map[string]bool, len(s.redaction.masked)+len(columns))
+		for name := range s.redaction.masked {
+			masked[name] = true
+		}
+		for _, c := range columns {
+			masked[strings.ToLower(c)] = true
+		}
+		s.redaction.masked = masked
+	})
+	return l
+}
+
+// maskColumns replaces the literals given to the columns by ***.
+func maskColumns(sql string, columns map[string]bool) string {
+	tokens := tokenize(sql)
+	mask := make([]bool, len(tokens))
+
+	// col = value
+	for i := 0; i+2 < len(tokens); i++ {
+		if tokens[i].kind == 'i' && columns[tokens[i].name] &&
+			sql[tokens[i+1].start] == '=' && tokens[i+1].kind == 'p' && tokens[i+2].kind == 'l' {
+			mask[i+2] = true
+		}
+	}
+
+	// INSERT INTO table (cols) VALUES (...), (...)
+	values := -1
+	for i, t := range tokens {
+		if t.kind == 'i' && t.name == "values" {
+			values = i
+			break
+		}
+	}
+	if values > 0 && tokens[values-1].kind == 'p' && sql[tokens[values-1].start] == ')' {
+		var cols []string
+		for i := values - 2; i >= 0; i-- {
+			t := tokens[i]
+			if t.kind == 'p' && sql[t.start] == '(' {
+				break
+			}
+			if t.kind == 'i' {
+				cols = append([]string{t.name}, cols...)
+			}
+		}
+
+		depth, pos := 0, 0
+		for i := values + 1; i < len(tokens); i++ {
+			t := tokens[i]
+			if t.kind == 'p' {
+				switch sql[t.start] {
+				case '(':
+					depth++
+					if depth == 1 {
+						pos = 0
+					}
+				case ')':
+					depth--
+				case ',':
+					if depth == 1 {
+						pos++
+					}
+				}
+				continue
+			}
+			if depth == 0 && t.kind == 'i' {
+				// ON CONFLICT, RETURNING...
+				break
+			}
+			if t.kind == 'l' && depth >= 1 && pos < len(cols) && columns[cols[pos]] {
+				mask[i] = true
+			}
+		}
+	}
+
+	var b strings.Builder
+	last := 0
+	for i, t := range tokens {
+		if mask[i] {
+			b.WriteString(sql[last:t.start])
+			b.WriteString("***")
+			last = t.end
+		}
+	}
+	if last == 0 {
+		return sql
+	}
+	b.WriteString(sql[last:])
+	return b.String()
+}
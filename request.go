@@ -0,0 +1,165 @@
+package cgLogger
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	lg "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+)
+
+type requestKey struct{}
+
+// The counts of a request are bounded for the long running jobs: the query shapes after the first
+// requestFingerprints, and the statements of a shape after its first requestStatements, are not counted.
+const (
+	requestFingerprints = 1024
+	requestStatements   = 128
+)
+
+// requestState is stored in the context of a request to count its queries.
+type requestState struct {
+	mu           sync.Mutex
+	start        time.Time
+	fingerprints map[string]*requestQuery
+	summary      RequestSummary
+}
+
+// requestQuery counts the executions of a query shape and of its identical statements. The statements
+// are known by the hash of their SQL, their values are not kept.
+type requestQuery struct {
+	executions int
+	statements map[uint64]int
+}
+
+// RequestSummary are the queries of a request, returned by EndRequest.
+type RequestSummary struct {
+	Queries     int
+	SlowQueries int
+	// Errors doesn't count ErrRecordNotFound.
+	Errors int
+	// DBTime is the sum of the durations of the queries, Elapsed the time since StartRequest.
+	DBTime  time.Duration
+	Elapsed time.Duration
+}
+
+// StartRequest marks ctx as the context of a request, the queries made with it are counted
+// by fingerprint and statement (see GormInfos.Executions, GormInfos.Repeats, NPlusOneTrigger,
+// DuplicateQueryTrigger and EndRequest):
+//
+//	func (h handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//		ctx := cgLogger.StartRequest(r.Context())
+//		defer logger.EndRequest(ctx)
+//		h.next.ServeHTTP(w, r.WithContext(ctx))
+//	}
+func StartRequest(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requestKey{}, &requestState{
+		start:        time.Now(),
+		fingerprints: map[string]*requestQuery{},
+	})
+}
+
+func requestFromContext(ctx context.Context) *requestState {
+	if ctx == nil {
+		return nil
+	}
+	r, _ := ctx.Value(requestKey{}).(*requestState)
+	return r
+}
+
+// record counts the query, returning the executions of its fingerprint and of the identical statement in the request,
+// 0 when they are over the bounds.
+func (r *requestState) record(fp, sql string, elapsed time.Duration, slow bool, err error) (executions, repeats int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.summary.Queries++
+	r.summary.DBTime += elapsed
+	if slow {
+		r.summary.SlowQueries++
+	}
+	if err != nil && !isNotFound(err) {
+		r.summary.Errors++
+	}
+
+	q := r.fingerprints[fp]
+	if q == nil {
+		if len(r.fingerprints) >= requestFingerprints {
+			return 0, 0
+		}
+		q = &requestQuery{statements: map[uint64]int{}}
+		r.fingerprints[fp] = q
+	}
+	q.executions++
+
+	h := hashString(sql)
+	if _, ok := q.statements[h]; !ok && len(q.statements) >= requestStatements {
+		return q.executions, 0
+	}
+	q.statements[h]++
+	return q.executions, q.statements[h]
+}
+
+// hashString is the 64 bits FNV-1a hash of s.
+func hashString(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// EndRequest returns the summary of the queries of the request started with StartRequest, giving it
+// to the OnRequestEnd callback and writing it in an Info line. It returns a zero summary for the other contexts.
+func (l customLogger) EndRequest(ctx context.Context) RequestSummary {
+	location := ""
+	if !l.config().DisableCaller {
+		location = l.skipCaller(utils.FileWithLineNum())
+	}
+	return l.endRequest(ctx, location)
+}
+
+func (l customLogger) endRequest(ctx context.Context, location string) RequestSummary {
+	r := requestFromContext(ctx)
+	if r == nil {
+		return RequestSummary{}
+	}
+
+	r.mu.Lock()
+	s := r.summary
+	r.mu.Unlock()
+	s.Elapsed = time.Since(r.start)
+
+	if f := l.execution().requestEnd; f != nil {
+		f(ctx, s)
+	}
+	if !noop {
+		l.printf(ctx, lg.Info, LevelInfo, location, "request: %d queries in %v", []interface{}{
+			s.Queries, s.DBTime, "slow", s.SlowQueries, "errors", s.Errors, "elapsed", s.Elapsed,
+		})
+	}
+	return s
+}
+
+// OnRequestEnd sets the function receiving the summary of each request, see EndRequest.
+func (l *customLogger) OnRequestEnd(f func(ctx context.Context, s RequestSummary)) CInterface {
+	l.registry.update(func(s *triggerState) {
+		s.requestEnd = f
+	})
+	return l
+}
+
+// Middleware wraps an HTTP handler with StartRequest and EndRequest:
+//
+//	http.ListenAndServe(":8080", logger.Middleware(mux))
+func (l customLogger) Middleware(next http.Handler) http.Handler {
+	location := utils.FileWithLineNum()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := StartRequest(r.Context())
+		defer l.endRequest(ctx, location)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,233 @@
+package cgLogger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupLayout is the time layout of the rotated files: app-2021-07-01T15-04-05.000.log
+const backupLayout = "2006-01-02T15-04-05.000"
+
+// RotatingFile is a Writer appending to Filename that rotates it by size and age:
+//
+//	file := &cgLogger.RotatingFile{Filename: "/var/log/app/gorm.log", MaxSize: 100 << 20, MaxBackups: 7, Compress: true}
+//	defer file.Close()
+//	logger := cgLogger.New(file, config)
+//
+// The file is opened on the first write. It is safe for concurrent use.
+type RotatingFile struct {
+	Filename string
+	// MaxSize in bytes before the file is rotated, defaults to 100MB.
+	MaxSize int64
+	// RotateEvery rotates the file once it is older, 0 disables it.
+	RotateEvery time.Duration
+	// MaxBackups is the max of rotated files kept, 0 keeps all of them.
+	MaxBackups int
+	// MaxAge removes the rotated files older than it, 0 keeps all of them.
+	MaxAge time.Duration
+	// Compress the rotated files with gzip.
+	Compress bool
+	// LocalTime names the rotated files with the local time instead of UTC.
+	LocalTime bool
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	cleaning sync.WaitGroup
+}
+
+// Printf writes the line, ending it with a new line.
+func (f *RotatingFile) Printf(format string, data ...interface{}) {
+	line := fmt.Sprintf(format, data...)
+	if !strings.HasSuffix(line, "\n") {
+		line += "\n"
+	}
+	_, _ = f.Write([]byte(line))
+}
+
+// Write implements io.Writer, rotating the file before p when it is full or too old.
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	if f.size > 0 && (f.size+int64(len(p)) > f.maxSize() || f.RotateEvery > 0 && time.Since(f.openedAt) >= f.RotateEvery) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Rotate closes the current file and starts a new one, ex: on SIGHUP.
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rotate()
+}
+
+// Close closes the file and waits for the compression of the rotated files.
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+
+	f.cleaning.Wait()
+	return err
+}
+
+func (f *RotatingFile) maxSize() int64 {
+	if f.MaxSize <= 0 {
+		return 100 << 20
+	}
+	return f.MaxSize
+}
+
+// open appends to the existing file, keeping its size and modification time.
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.Filename), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file, f.size, f.openedAt = file, info.Size(), time.Now()
+	if info.Size() > 0 {
+		f.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+func (f *RotatingFile) rotate() error {
+	if f.file != nil {
+		if err := f.file.Close(); err != nil {
+			return err
+		}
+		f.file = nil
+	}
+
+	now := time.Now()
+	if !f.LocalTime {
+		now = now.UTC()
+	}
+	ext := filepath.Ext(f.Filename)
+	backup := strings.TrimSuffix(f.Filename, ext) + "-" + now.Format(backupLayout) + ext
+	if err := os.Rename(f.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.cleaning.Add(1)
+	go func() {
+		defer f.cleaning.Done()
+		if f.Compress {
+			_ = compressFile(backup)
+		}
+		f.removeOld()
+	}()
+	return nil
+}
+
+// backups returns the rotated files, newest first.
+func (f *RotatingFile) backups() []string {
+	ext := filepath.Ext(f.Filename)
+	prefix := filepath.Base(strings.TrimSuffix(f.Filename, ext)) + "-"
+
+	entries, err := os.ReadDir(filepath.Dir(f.Filename))
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		if e.IsDir() || !strings.HasPrefix(stamp, prefix) {
+			continue
+		}
+		if _, err := time.Parse(backupLayout, stamp[len(prefix):]); err == nil {
+			names = append(names, name)
+		}
+	}
+	// the layout sorts as the time.
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names
+}
+
+// removeOld deletes the backups beyond MaxBackups or older than MaxAge.
+func (f *RotatingFile) removeOld() {
+	if f.MaxBackups <= 0 && f.MaxAge <= 0 {
+		return
+	}
+
+	dir := filepath.Dir(f.Filename)
+	for i, name := range f.backups() {
+		path := filepath.Join(dir, name)
+		old := false
+		if f.MaxAge > 0 {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > f.MaxAge {
+				old = true
+			}
+		}
+		if old || f.MaxBackups > 0 && i >= f.MaxBackups {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// compressFile replaces path by path.gz.
+func compressFile(path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(path + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
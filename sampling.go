@@ -0,0 +1,16 @@
+package cgLogger
+
+import "sync/atomic"
+
+// sampler counts the Info traces for Config.SampleInfo, it is shared by the loggers derived with LogMode.
+type sampler struct {
+	n uint64
+}
+
+// keep reports if the trace is the first of each group of every traces, every <= 1 keeps all of them.
+func (s *sampler) keep(every int) bool {
+	if every <= 1 {
+		return true
+	}
+	return (atomic.AddUint64(&s.n, 1)-1)%uint64(every) == 0
+}
@@ -0,0 +1,56 @@
+package cgLogger
+
+import (
+	"time"
+
+	lg "gorm.io/gorm/logger"
+)
+
+// LevelRange sets Level from the time of day From until To (exclusive).
+// From and To are offsets since midnight, use Clock to build them.
+// When From is after To the range crosses midnight, ex: Clock(22, 0) to Clock(6, 0).
+type LevelRange struct {
+	From  time.Duration
+	To    time.Duration
+	Level lg.LogLevel
+}
+
+// LevelSchedule changes the log level according to the time of day.
+// Outside all ranges the LogLevel of the logger is used.
+type LevelSchedule struct {
+	Ranges []LevelRange
+	// Location used to read the time of day, defaults to time.Local.
+	Location *time.Location
+}
+
+// Clock returns the offset since midnight of hour:minute, to be used in a LevelRange.
+func Clock(hour, minute int) time.Duration {
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+}
+
+func (r LevelRange) contains(d time.Duration) bool {
+	if r.From <= r.To {
+		return d >= r.From && d < r.To
+	}
+	return d >= r.From || d < r.To
+}
+
+// level returns the level scheduled for t, ok is false if no range matches.
+func (s *LevelSchedule) level(t time.Time) (lg.LogLevel, bool) {
+	if s == nil {
+		return 0, false
+	}
+
+	if s.Location != nil {
+		t = t.In(s.Location)
+	}
+	h, m, sec := t.Clock()
+	d := Clock(h, m) + time.Duration(sec)*time.Second
+
+	for _, r := range s.Ranges {
+		if r.contains(d) {
+			return r.Level, true
+		}
+	}
+	return 0, false
+}
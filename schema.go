@@ -0,0 +1,63 @@
+package cgLogger
+
+import "fmt"
+
+// Compatibility policy of the structured formats (JSON and the ones added after it):
+//   - adding a field keeps SchemaVersion, parsers must ignore the fields they don't know;
+//   - renaming, removing or changing the meaning of a field increments SchemaVersion
+//     and adds a migration from the previous version to migrations.
+const (
+	// SchemaVersion is written as schema_version in every structured line.
+	SchemaVersion = 1
+	// SchemaVersionKey is the key holding the version.
+	SchemaVersionKey = "schema_version"
+)
+
+// migrations upgrade a record from the version of the key to the next one.
+var migrations = map[int]func(record map[string]interface{}){
+	// 0 is the JSON written before schema_version existed, it has the same fields as 1.
+	0: func(record map[string]interface{}) {},
+}
+
+// RecordVersion returns the schema version of a decoded structured line, 0 if it has none.
+func RecordVersion(record map[string]interface{}) (int, error) {
+	v, ok := record[SchemaVersionKey]
+	if !ok {
+		return 0, nil
+	}
+
+	switch v := v.(type) {
+	case float64:
+		return int(v), nil
+	case int:
+		return v, nil
+	case string:
+		var n int
+		if _, err := fmt.Sscanf(v, "%d", &n); err == nil {
+			return n, nil
+		}
+	}
+	return 0, fmt.Errorf("cgLogger: invalid %s %v", SchemaVersionKey, v)
+}
+
+// MigrateRecord upgrades, in place, a decoded structured line to SchemaVersion
+// so downstream parsers only need to understand the current layout.
+func MigrateRecord(record map[string]interface{}) (map[string]interface{}, error) {
+	version, err := RecordVersion(record)
+	if err != nil {
+		return nil, err
+	}
+	if version > SchemaVersion {
+		return nil, fmt.Errorf("cgLogger: %s %d is newer than %d", SchemaVersionKey, version, SchemaVersion)
+	}
+
+	for ; version < SchemaVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return nil, fmt.Errorf("cgLogger: no migration from %s %d", SchemaVersionKey, version)
+		}
+		migrate(record)
+	}
+	record[SchemaVersionKey] = SchemaVersion
+	return record, nil
+}
@@ -0,0 +1,243 @@
+package cgLogger
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SentryConfig configures a SentrySink, only DSN is required.
+type SentryConfig struct {
+	// DSN of the project, ex: https://<key>@o0.ingest.sentry.io/<project>
+	DSN         string
+	Environment string
+	Release     string
+	// ServerName defaults to the hostname.
+	ServerName string
+
+	// RedactSQL sends the fingerprint of the query (literals replaced by ?) instead of the query with its values.
+	RedactSQL bool
+	// Redact, when set, is applied to the query sent, it takes precedence over RedactSQL.
+	Redact func(sql string) string
+
+	// MaxPerMinute is the max of events sent per minute, the others are dropped. Defaults to 30, -1 disables the limit.
+	MaxPerMinute int
+	// QueueSize is the max of events waiting to be sent, defaults to 1000.
+	QueueSize int
+
+	// Client defaults to a client with a 10s timeout.
+	Client *http.Client
+	// OnError receives the failed requests, by default they are ignored.
+	OnError func(error)
+}
+
+// SentrySink reports the query errors to Sentry with the query, duration and caller as context.
+// The events are grouped by query fingerprint and rate limited, the Retry-After of Sentry is respected.
+//
+//	sink, err := cgLogger.NewSentrySink(cgLogger.SentryConfig{DSN: os.Getenv("SENTRY_DSN"), RedactSQL: true})
+//	defer sink.Close()
+//	logger.ErrorTrigger(sink.Trigger)
+type SentrySink struct {
+	config   SentryConfig
+	endpoint string
+	auth     string
+	throttle *throttle
+	batcher  *batcher
+}
+
+// sentryEvent is an error waiting to be sent.
+type sentryEvent struct {
+	at    time.Time
+	infos GormInfos
+}
+
+// NewSentrySink parses the DSN and starts the goroutine sending the events, Close stops it.
+func NewSentrySink(config SentryConfig) (*SentrySink, error) {
+	endpoint, auth, err := parseSentryDSN(config.DSN)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.ServerName == "" {
+		config.ServerName, _ = os.Hostname()
+	}
+	if config.MaxPerMinute == 0 {
+		config.MaxPerMinute = 30
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	s := &SentrySink{
+		config:   config,
+		endpoint: endpoint,
+		auth:     auth,
+		throttle: newThrottle(config.MaxPerMinute, time.Minute),
+	}
+	s.batcher = newBatcher(config.QueueSize, 1, time.Second, s.send)
+	return s, nil
+}
+
+// parseSentryDSN returns the store endpoint and the X-Sentry-Auth header of the DSN.
+func parseSentryDSN(raw string) (endpoint, auth string, err error) {
+	dsn, err := url.Parse(raw)
+	if err != nil {
+		return "", "", fmt.Errorf("cgLogger: invalid sentry DSN: %w", err)
+	}
+	project := strings.Trim(dsn.Path, "/")
+	if dsn.User == nil || dsn.User.Username() == "" || project == "" {
+		return "", "", fmt.Errorf("cgLogger: invalid sentry DSN: missing key or project")
+	}
+	// the path before the project id is kept for self-hosted instances under a prefix.
+	prefix := ""
+	if i := strings.LastIndexByte(project, '/'); i >= 0 {
+		prefix, project = "/"+project[:i], project[i+1:]
+	}
+
+	endpoint = fmt.Sprintf("%s://%s%s/api/%s/store/", dsn.Scheme, dsn.Host, prefix, project)
+	auth = "Sentry sentry_version=7, sentry_client=cglogger/1, sentry_key=" + dsn.User.Username()
+	if secret, ok := dsn.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	return endpoint, auth, nil
+}
+
+// Trigger queues the error, it is meant to be passed to ErrorTrigger. Traces without error are ignored.
+func (s *SentrySink) Trigger(g GormInfos) {
+	if g.Err == nil {
+		return
+	}
+	now := time.Now()
+	if !s.throttle.allow(now) {
+		return
+	}
+	s.batcher.add(sentryEvent{at: now, infos: g})
+}
+
+// Dropped returns how many errors were not sent because of the rate limit or a full queue.
+func (s *SentrySink) Dropped() int64 {
+	return s.throttle.droppedCount() + s.batcher.dropCount()
+}
+
+// Flush sends the queued events and waits for them.
+func (s *SentrySink) Flush() {
+	s.batcher.sync()
+}
+
+// Close sends the queued events and stops the sink.
+func (s *SentrySink) Close() error {
+	s.batcher.close()
+	return nil
+}
+
+func (s *SentrySink) send(batch []interface{}) {
+	for _, v := range batch {
+		e := v.(sentryEvent)
+		body, err := json.Marshal(s.event(e))
+		if err != nil {
+			s.fail(err)
+			continue
+		}
+
+		req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+		if err != nil {
+			s.fail(err)
+			continue
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Sentry-Auth", s.auth)
+
+		resp, err := s.config.Client.Do(req)
+		if err != nil {
+			s.fail(err)
+			continue
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			s.throttle.pause(e.at.Add(retryAfter(resp.Header.Get("Retry-After"))))
+		}
+		if resp.StatusCode >= 300 {
+			s.fail(responseError("sentry", resp))
+		}
+		resp.Body.Close()
+	}
+}
+
+// event builds the payload of the store endpoint.
+func (s *SentrySink) event(e sentryEvent) map[string]interface{} {
+	g := e.infos
+
+	sql := g.Sql
+	switch {
+	case s.config.Redact != nil:
+		sql = s.config.Redact(sql)
+	case s.config.RedactSQL:
+		sql = fingerprint(sql)
+	}
+
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	event := map[string]interface{}{
+		"event_id":  hex.EncodeToString(id),
+		"timestamp": e.at.UTC().Format(time.RFC3339Nano),
+		"level":     "error",
+		"logger":    "cglogger",
+		"platform":  "go",
+		"culprit":   g.Location,
+		"exception": map[string]interface{}{
+			"values": []map[string]interface{}{{
+				"type":  fmt.Sprintf("%T", g.Err),
+				"value": g.Err.Error(),
+			}},
+		},
+		// the errors of the same query are grouped whatever the values.
+		"fingerprint": []string{"{{ default }}", g.fingerprint()},
+		"tags": map[string]string{
+			"db.operation": strings.ToLower(sqlVerb(g.Sql)),
+		},
+		"extra": map[string]interface{}{
+			"sql":         sql,
+			"duration_ms": g.QueryDuration,
+			"rows":        g.AffectedRows,
+			"caller":      g.Location,
+		},
+		"server_name": s.config.ServerName,
+	}
+	if s.config.Environment != "" {
+		event["environment"] = s.config.Environment
+	}
+	if s.config.Release != "" {
+		event["release"] = s.config.Release
+	}
+	if g.TraceID != "" {
+		event["contexts"] = map[string]interface{}{
+			"trace": map[string]string{"trace_id": g.TraceID, "span_id": g.SpanID},
+		}
+	}
+	return event
+}
+
+func (s *SentrySink) fail(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
+
+// retryAfter parses the Retry-After header (seconds), defaults to a minute.
+func retryAfter(header string) time.Duration {
+	if n, err := strconv.Atoi(strings.TrimSpace(header)); err == nil && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return time.Minute
+}
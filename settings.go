@@ -0,0 +1,227 @@
+package cgLogger
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lg "gorm.io/gorm/logger"
+)
+
+// settings holds the Config and the state set by the methods of the logger (Redact, IgnoreQueries...),
+// shared by the loggers derived with LogMode. It is swapped atomically so it can be changed while gorm
+// is using the logger.
+type settings struct {
+	mu      sync.Mutex
+	current atomic.Value
+}
+
+// settingsSnapshot is never modified once stored.
+type settingsSnapshot struct {
+	config Config
+	style  textStyle
+	// plain is the style without colors, for the Windows consoles that can't render them.
+	plain textStyle
+	// version is increased by reload, discarding the levels set by LogMode before it.
+	version uint64
+	// levelSet is true from SetLogLevel until the next Reload or Schedule, the level then takes
+	// precedence over the schedule.
+	levelSet bool
+
+	// The state set by the methods of the logger, it is not part of the Config and is kept by update,
+	// reload and setLevel.
+	redaction    redaction
+	filter       *queryFilter
+	healthChecks bool
+	limiter      *fingerprintLimiter
+	dedup        *errorDedup
+	slowest      *slowTracker
+	percentiles  *percentileTracker
+	recent       *recentRing
+	schedule     *LevelSchedule
+}
+
+// redaction holds the rules of Redact and the columns of MaskColumns, it is replaced, never modified.
+type redaction struct {
+	rules  []RedactRule
+	masked map[string]bool
+}
+
+// levelMode is the level set by LogMode, in effect while the settings are at version.
+type levelMode struct {
+	level   lg.LogLevel
+	version uint64
+}
+
+func newSettings(config Config) *settings {
+	s := &settings{}
+	s.current.Store(&settingsSnapshot{config: config, style: config.textStyle(), plain: config.plainStyle()})
+	return s
+}
+
+func (s *settings) load() *settingsSnapshot {
+	return s.current.Load().(*settingsSnapshot)
+}
+
+func (s *settings) get() Config {
+	return s.load().config
+}
+
+// update applies f to a copy of the Config.
+func (s *settings) update(f func(c *Config)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := *s.load()
+	f(&snapshot.config)
+	snapshot.style, snapshot.plain = snapshot.config.textStyle(), snapshot.config.plainStyle()
+	s.current.Store(&snapshot)
+}
+
+// reload replaces the Config, the levels set by LogMode stop being applied.
+func (s *settings) reload(config Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := *s.load()
+	snapshot.config, snapshot.style, snapshot.plain = config, config.textStyle(), config.plainStyle()
+	snapshot.version++
+	snapshot.levelSet = false
+	s.current.Store(&snapshot)
+}
+
+// setLevel changes the LogLevel, as reload the levels set by LogMode stop being applied.
+func (s *settings) setLevel(level lg.LogLevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := *s.load()
+	snapshot.config.LogLevel = level
+	snapshot.version++
+	snapshot.levelSet = true
+	s.current.Store(&snapshot)
+}
+
+// change applies f to a copy of the snapshot, to set the state of the methods of the logger.
+// f replaces the values it changes, the ones of the previous snapshot must not be modified.
+func (s *settings) change(f func(snapshot *settingsSnapshot)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := *s.load()
+	f(&snapshot)
+	s.current.Store(&snapshot)
+}
+
+// textStyle returns the format strings of the text lines of the configuration.
+func (c Config) textStyle() textStyle {
+	style := newTextStyle(c.Colorful, c.Theme)
+	if c.DisableCaller {
+		return style.withoutCaller()
+	}
+	return style
+}
+
+// plainStyle returns the format strings of the text lines without colors.
+func (c Config) plainStyle() textStyle {
+	c.Colorful = false
+	return c.textStyle()
+}
+
+// timeLayout returns the layout of the time of the JSON and logfmt formats.
+func (c Config) timeLayout() string {
+	if c.TimeFormat != "" {
+		return c.TimeFormat
+	}
+	return time.RFC3339Nano
+}
+
+// config returns a snapshot of the current configuration.
+func (l customLogger) config() Config {
+	return l.settings.get()
+}
+
+// Level returns the LogLevel in effect, the Schedule is taken into account.
+func (l customLogger) Level() lg.LogLevel {
+	return l.level()
+}
+
+// slowThreshold returns the SlowThreshold of a statement on the tables, or of the operation.
+func (c Config) slowThreshold(operation string, tables []string) time.Duration {
+	if len(c.TableThresholds) > 0 {
+		var (
+			max   time.Duration
+			found bool
+		)
+		for name, d := range c.TableThresholds {
+			for _, t := range tables {
+				if strings.EqualFold(name, t) && (!found || d > max) {
+					max, found = d, true
+				}
+			}
+		}
+		if found {
+			return max
+		}
+	}
+	if d, ok := c.OperationThresholds[operation]; ok {
+		return d
+	}
+	return c.SlowThreshold
+}
+
+// minSlowThreshold returns the lowest of the thresholds that are set, a query under it can't be slow.
+// It is 0 when none is set.
+func (c Config) minSlowThreshold() time.Duration {
+	min := c.SlowThreshold
+	lower := func(d time.Duration) {
+		if d != 0 && (min == 0 || d < min) {
+			min = d
+		}
+	}
+	for _, d := range c.OperationThresholds {
+		lower(d)
+	}
+	for _, d := range c.TableThresholds {
+		lower(d)
+	}
+	lower(c.CriticalThreshold)
+	return min
+}
+
+// SlowThreshold returns the duration from which a query is logged as SLOW SQL.
+func (l customLogger) SlowThreshold() time.Duration {
+	return l.config().SlowThreshold
+}
+
+// Format returns the format of the log lines.
+func (l customLogger) Format() Format {
+	return l.config().Format
+}
+
+// Reload replaces the configuration of the logger and the loggers derived with LogMode (their level included),
+// it is applied atomically: a query uses either the old or the new configuration.
+func (l *customLogger) Reload(config Config) CInterface {
+	l.settings.reload(config)
+	return l
+}
+
+// SetLogLevel changes the level of the logger and the loggers derived with LogMode, it takes effect
+// immediately and is safe to call while gorm is using the logger, ex: Info while debugging then back to Warn.
+// It takes precedence over the Schedule until the next Reload or Schedule.
+func (l *customLogger) SetLogLevel(level lg.LogLevel) CInterface {
+	l.settings.setLevel(level)
+	return l
+}
+
+// ParamsFilter implements the logger.ParamsFilter of gorm (>= 1.25): with ParameterizedQueries the params
+// are not bound in the statement given to Trace. Older versions of gorm (ex: v1.21) never call it,
+// the literals of the bound statements are replaced by stripLiterals.
+func (l customLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.config().ParameterizedQueries {
+		return sql, nil
+	}
+	return sql, params
+}
@@ -0,0 +1,133 @@
+package cgLogger
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+// SlackTemplate is the default text of the messages, executed with the GormInfos of the trace.
+const SlackTemplate = "{{if .Err}}:red_circle: *Query error*: {{.Err}}{{else}}:turtle: *Slow query*: {{printf \"%.3f\" .QueryDuration}}ms{{end}}\n" +
+	"`{{.Location}}` rows: {{.AffectedRows}}\n" +
+	"```{{.Sql}}```"
+
+// SlackConfig configures a SlackSink, only WebhookURL is required.
+type SlackConfig struct {
+	// WebhookURL of the incoming webhook.
+	WebhookURL string
+	// Template of the text (text/template over GormInfos), defaults to SlackTemplate.
+	Template string
+	// Channel, Username and IconEmoji override the ones of the webhook when set.
+	Channel   string
+	Username  string
+	IconEmoji string
+
+	// MaxPerMinute is the max of messages posted per minute, the others are dropped. Defaults to 10, -1 disables the limit.
+	MaxPerMinute int
+	// QueueSize is the max of messages waiting to be posted, defaults to 100.
+	QueueSize int
+
+	// Client defaults to a client with a 10s timeout.
+	Client *http.Client
+	// OnError receives the failed requests, by default they are ignored.
+	OnError func(error)
+}
+
+// SlackSink posts a message to a Slack incoming webhook for every trace it receives.
+//
+//	sink, err := cgLogger.NewSlackSink(cgLogger.SlackConfig{WebhookURL: url, MaxPerMinute: 5})
+//	defer sink.Close()
+//	logger.SlowTrigger(sink.Trigger)
+//	logger.ErrorTrigger(sink.Trigger)
+type SlackSink struct {
+	config   SlackConfig
+	template *template.Template
+	throttle *throttle
+	batcher  *batcher
+}
+
+// NewSlackSink parses the template and starts the goroutine posting the messages, Close stops it.
+func NewSlackSink(config SlackConfig) (*SlackSink, error) {
+	if config.Template == "" {
+		config.Template = SlackTemplate
+	}
+	tmpl, err := template.New("slack").Parse(config.Template)
+	if err != nil {
+		return nil, err
+	}
+	if config.MaxPerMinute == 0 {
+		config.MaxPerMinute = 10
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 100
+	}
+	if config.Client == nil {
+		config.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	s := &SlackSink{config: config, template: tmpl, throttle: newThrottle(config.MaxPerMinute, time.Minute)}
+	s.batcher = newBatcher(config.QueueSize, 1, time.Second, s.send)
+	return s, nil
+}
+
+// Trigger queues the message, it is meant to be passed to SlowTrigger and ErrorTrigger.
+func (s *SlackSink) Trigger(g GormInfos) {
+	if !s.throttle.allow(time.Now()) {
+		return
+	}
+
+	var text bytes.Buffer
+	if err := s.template.Execute(&text, g); err != nil {
+		s.fail(err)
+		return
+	}
+	s.batcher.add(text.String())
+}
+
+// Dropped returns how many messages were not posted because of the throttling or a full queue.
+func (s *SlackSink) Dropped() int64 {
+	return s.throttle.droppedCount() + s.batcher.dropCount()
+}
+
+// Flush posts the queued messages and waits for them.
+func (s *SlackSink) Flush() {
+	s.batcher.sync()
+}
+
+// Close posts the queued messages and stops the sink.
+func (s *SlackSink) Close() error {
+	s.batcher.close()
+	return nil
+}
+
+func (s *SlackSink) send(batch []interface{}) {
+	for _, v := range batch {
+		body, _ := json.Marshal(struct {
+			Text      string `json:"text"`
+			Channel   string `json:"channel,omitempty"`
+			Username  string `json:"username,omitempty"`
+			IconEmoji string `json:"icon_emoji,omitempty"`
+		}{v.(string), s.config.Channel, s.config.Username, s.config.IconEmoji})
+
+		resp, err := s.config.Client.Post(s.config.WebhookURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			s.fail(err)
+			continue
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			s.throttle.pause(time.Now().Add(retryAfter(resp.Header.Get("Retry-After"))))
+		}
+		if resp.StatusCode >= 300 {
+			s.fail(responseError("slack", resp))
+		}
+		resp.Body.Close()
+	}
+}
+
+func (s *SlackSink) fail(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
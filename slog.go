@@ -0,0 +1,67 @@
+//go:build go1.21
+// +build go1.21
+
+package cgLogger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// SlogWriter writes to a *slog.Logger keeping the level and the fields of the lines:
+//
+//	logger := cgLogger.New(cgLogger.NewSlogWriter(slog.Default()), config)
+//
+// Traces are logged with the message "sql" and the attributes caller, duration, rows, sql and error.
+type SlogWriter struct {
+	Logger *slog.Logger
+}
+
+// NewSlogWriter returns a Writer logging to l.
+func NewSlogWriter(l *slog.Logger) SlogWriter {
+	return SlogWriter{Logger: l}
+}
+
+// Printf logs the lines that don't come as entries at the Info level.
+func (w SlogWriter) Printf(format string, data ...interface{}) {
+	w.Logger.Info(fmt.Sprintf(format, data...))
+}
+
+// WriteEntry logs e at the matching slog level.
+func (w SlogWriter) WriteEntry(e Entry) {
+	ctx := e.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	attrs := make([]slog.Attr, 0, len(e.Fields)+5)
+	attrs = append(attrs, slog.String("caller", e.Location))
+	if e.Trace {
+		attrs = append(attrs,
+			slog.Duration("duration", e.Duration),
+			slog.Int64("rows", e.Rows),
+			slog.String("sql", e.SQL),
+		)
+	}
+	if e.Err != nil {
+		attrs = append(attrs, slog.String("error", e.Err.Error()))
+	}
+	for _, f := range e.Fields {
+		attrs = append(attrs, slog.Any(f.Key, f.Value))
+	}
+
+	w.Logger.LogAttrs(ctx, slogLevel(e.Level), e.message(), attrs...)
+}
+
+func slogLevel(level string) slog.Level {
+	switch level {
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
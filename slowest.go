@@ -0,0 +1,151 @@
+package cgLogger
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	lg "gorm.io/gorm/logger"
+)
+
+// SlowQuery are the executions of a fingerprint, returned by TopSlow.
+type SlowQuery struct {
+	Fingerprint string
+	Count       int64
+	Max         time.Duration
+	Avg         time.Duration
+	// SQL and Location are the ones of the slowest execution.
+	SQL      string
+	Location string
+}
+
+// maxSlowFingerprints bounds the fingerprints tracked for TopSlow, the fastest one is forgotten for a new one.
+const maxSlowFingerprints = 10000
+
+// slowTracker keeps the executions by fingerprint for TopSlow, it is shared by the loggers derived with LogMode.
+type slowTracker struct {
+	n int
+
+	mu      sync.Mutex
+	queries map[string]*slowQuery
+}
+
+type slowQuery struct {
+	count    int64
+	total    time.Duration
+	max      time.Duration
+	sql      string
+	location string
+}
+
+func (t *slowTracker) record(g GormInfos) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	q := t.queries[g.Fingerprint]
+	if q == nil {
+		if len(t.queries) >= maxSlowFingerprints && !t.evict(g.Elapsed) {
+			return
+		}
+		q = &slowQuery{}
+		t.queries[g.Fingerprint] = q
+	}
+	q.count++
+	q.total += g.Elapsed
+	if g.Elapsed >= q.max {
+		q.max, q.sql, q.location = g.Elapsed, g.Sql, g.Location
+	}
+}
+
+// evict forgets the fastest fingerprint if it is faster than d.
+func (t *slowTracker) evict(d time.Duration) bool {
+	var (
+		fastest string
+		min     time.Duration = -1
+	)
+	for fp, q := range t.queries {
+		if min < 0 || q.max < min {
+			fastest, min = fp, q.max
+		}
+	}
+	if min >= d {
+		return false
+	}
+	delete(t.queries, fastest)
+	return true
+}
+
+func (t *slowTracker) top() []SlowQuery {
+	t.mu.Lock()
+	out := make([]SlowQuery, 0, len(t.queries))
+	for fp, q := range t.queries {
+		out = append(out, SlowQuery{
+			Fingerprint: fp,
+			Count:       q.count,
+			Max:         q.max,
+			Avg:         q.total / time.Duration(q.count),
+			SQL:         q.sql,
+			Location:    q.location,
+		})
+	}
+	t.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Max != out[j].Max {
+			return out[i].Max > out[j].Max
+		}
+		return out[i].Fingerprint < out[j].Fingerprint
+	})
+	if len(out) > t.n {
+		out = out[:t.n]
+	}
+	return out
+}
+
+// TrackSlowest keeps the n slowest query fingerprints in memory, see TopSlow. A zero n turns it off.
+// It can be changed while gorm is using the logger, calling it again forgets the queries kept.
+func (l *customLogger) TrackSlowest(n int) CInterface {
+	var slowest *slowTracker
+	if n > 0 {
+		slowest = &slowTracker{n: n, queries: map[string]*slowQuery{}}
+	}
+	l.settings.change(func(s *settingsSnapshot) { s.slowest = slowest })
+	return l
+}
+
+// TopSlow returns the slowest query fingerprints by max duration, the slowest first. It is empty without TrackSlowest.
+func (l *customLogger) TopSlow() []SlowQuery {
+	slowest := l.settings.load().slowest
+	if slowest == nil {
+		return nil
+	}
+	return slowest.top()
+}
+
+// DumpTopSlow writes the TopSlow list at the Info level every interval, until the returned function is called.
+// Nothing is written when interval is <= 0, the returned function does nothing.
+func (l *customLogger) DumpTopSlow(interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				for i, q := range l.TopSlow() {
+					l.printf(context.Background(), lg.Info, LevelInfo, q.Location, "top slow #%d: %s", []interface{}{
+						i + 1, q.Fingerprint, "count", q.Count, "max", q.Max, "avg", q.Avg, "sql", q.SQL,
+					})
+				}
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
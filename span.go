@@ -0,0 +1,73 @@
+package cgLogger
+
+import (
+	"context"
+	"time"
+)
+
+// Span is a query as a tracing span, see SpanRecorder.
+type Span struct {
+	// Name is the operation of the statement (SELECT, INSERT...).
+	Name      string
+	Start     time.Time
+	End       time.Time
+	Statement string
+	Rows      int64
+	Location  string
+	Err       error
+}
+
+// Attributes returns the attributes of the span using the OpenTelemetry semantic conventions.
+func (s Span) Attributes() map[string]interface{} {
+	attrs := map[string]interface{}{
+		"db.statement":     s.Statement,
+		"db.operation":     s.Name,
+		"db.rows_affected": s.Rows,
+		"db.duration_ms":   float64(s.End.Sub(s.Start).Nanoseconds()) / 1e6,
+		"code.location":    s.Location,
+	}
+	if s.Err != nil {
+		attrs["error.message"] = s.Err.Error()
+	}
+	return attrs
+}
+
+// SpanRecorder records a span per query in the context passed to Trace, it is declared here
+// so the package doesn't depend on a tracing SDK. With OpenTelemetry:
+//
+//	func (r otelRecorder) RecordSpan(ctx context.Context, s cgLogger.Span) {
+//		_, span := r.tracer.Start(ctx, s.Name, trace.WithTimestamp(s.Start), trace.WithSpanKind(trace.SpanKindClient))
+//		for k, v := range s.Attributes() {
+//			span.SetAttributes(attribute.String(k, fmt.Sprint(v)))
+//		}
+//		if s.Err != nil {
+//			span.RecordError(s.Err)
+//			span.SetStatus(codes.Error, s.Err.Error())
+//		}
+//		span.End(trace.WithTimestamp(s.End))
+//	}
+type SpanRecorder interface {
+	RecordSpan(ctx context.Context, s Span)
+}
+
+// recordSpan sends the query to the recorder, ctx defaults to context.Background().
+func recordSpan(r SpanRecorder, ctx context.Context, g GormInfos, begin time.Time, elapsed time.Duration) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	name := sqlVerb(g.Sql)
+	if name == "" {
+		name = "sql"
+	}
+
+	r.RecordSpan(ctx, Span{
+		Name:      name,
+		Start:     begin,
+		End:       begin.Add(elapsed),
+		Statement: g.Sql,
+		Rows:      g.AffectedRows,
+		Location:  g.Location,
+		Err:       g.Err,
+	})
+}
@@ -0,0 +1,204 @@
+package cgLogger
+
+import (
+	"context"
+	"database/sql/driver"
+	"time"
+)
+
+// sqlCallers are skipped when resolving the location of a database/sql query.
+var sqlCallers = []string{"database/sql.", "github.com/jmoiron/sqlx."}
+
+// WrapDriver returns a database/sql driver that traces every statement executed through d,
+// so code using database/sql directly or sqlx gets the same triggers and output as gorm:
+//
+//	sql.Register("postgres-logged", cgLogger.WrapDriver(&pq.Driver{}, logger))
+//	db, err := sqlx.Open("postgres-logged", dsn)
+//
+// Queries are traced with -1 rows as the result set is read after the call.
+func WrapDriver(d driver.Driver, l CInterface) driver.Driver {
+	return tracedDriver{Driver: d, l: l}
+}
+
+type tracedDriver struct {
+	driver.Driver
+	l CInterface
+}
+
+func (d tracedDriver) Open(name string) (driver.Conn, error) {
+	c, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &tracedConn{Conn: c, l: d.l}, nil
+}
+
+type tracedConn struct {
+	driver.Conn
+	l CInterface
+}
+
+func (c *tracedConn) trace(ctx context.Context, begin time.Time, query string, args []driver.NamedValue, rows int64, err error) {
+	if noop || err == driver.ErrSkip {
+		return
+	}
+	location := callerOutside(sqlCallers...)
+	traceAt(c.l, ctx, location, begin, func() (string, int64) {
+		return explainNamed(query, args), rows
+	}, err)
+}
+
+func (c *tracedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	begin := time.Now()
+	res, err := execer.ExecContext(ctx, query, args)
+	rows := int64(-1)
+	if err == nil {
+		if n, rErr := res.RowsAffected(); rErr == nil {
+			rows = n
+		}
+	}
+	c.trace(ctx, begin, query, args, rows, err)
+	return res, err
+}
+
+func (c *tracedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+
+	begin := time.Now()
+	rows, err := queryer.QueryContext(ctx, query, args)
+	c.trace(ctx, begin, query, args, -1, err)
+	return rows, err
+}
+
+func (c *tracedConn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *tracedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tracedStmt{Stmt: stmt, conn: c, query: query}, nil
+}
+
+func (c *tracedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *tracedConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *tracedConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *tracedConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+type tracedStmt struct {
+	driver.Stmt
+	conn  *tracedConn
+	query string
+}
+
+func (s *tracedStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	begin := time.Now()
+
+	var (
+		res driver.Result
+		err error
+	)
+	if e, ok := s.Stmt.(driver.StmtExecContext); ok {
+		res, err = e.ExecContext(ctx, args)
+	} else {
+		res, err = s.Stmt.Exec(namedToValues(args))
+	}
+
+	rows := int64(-1)
+	if err == nil {
+		if n, rErr := res.RowsAffected(); rErr == nil {
+			rows = n
+		}
+	}
+	s.conn.trace(ctx, begin, s.query, args, rows, err)
+	return res, err
+}
+
+func (s *tracedStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	begin := time.Now()
+
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if q, ok := s.Stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.Stmt.Query(namedToValues(args))
+	}
+
+	s.conn.trace(ctx, begin, s.query, args, -1, err)
+	return rows, err
+}
+
+func (s *tracedStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := s.Stmt.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return s.conn.CheckNamedValue(nv)
+}
+
+func namedToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	return values
+}
+
+// explainNamed binds the args in the query, see explainSQL.
+func explainNamed(query string, args []driver.NamedValue) string {
+	vars := make([]interface{}, len(args))
+	for i, a := range args {
+		vars[i] = a.Value
+	}
+	return explainSQL(query, vars)
+}
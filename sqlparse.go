@@ -0,0 +1,629 @@
+package cgLogger
+
+import (
+	"bytes"
+	"strings"
+	"unicode"
+)
+
+// sqlVerb returns the upper case command of the statement (SELECT, INSERT...) skipping
+// comments and parentheses. The main command of a WITH statement is returned.
+func sqlVerb(sql string) string {
+	sql = strings.TrimLeft(sql, " \t\r\n(")
+	verb := ""
+	eachSQLWord(sql, func(w string) bool {
+		switch {
+		case verb == "":
+			verb = w
+			return verb == "WITH"
+		case w == "SELECT", w == "INSERT", w == "UPDATE", w == "DELETE", w == "MERGE":
+			verb = w
+			return false
+		}
+		return true
+	})
+	return verb
+}
+
+// sqlWords returns the upper case words of sql out of quotes and comments.
+// Only the words at the parenthesis depth 0 are returned, if max is 0 just the first one is.
+func sqlWords(sql string, max int) []string {
+	var words []string
+	eachSQLWord(sql, func(w string) bool {
+		words = append(words, w)
+		return max != 0 && len(words) != max
+	})
+	return words
+}
+
+// eachSQLWord calls f with the upper case words of sql at the parenthesis depth 0, out of quotes and comments,
+// until f returns false. The words already in upper case, the keywords written by gorm, are not copied.
+func eachSQLWord(sql string, f func(w string) bool) {
+	depth := 0
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			if j := strings.IndexByte(sql[i:], '\n'); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(sql)
+			}
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			if j := strings.Index(sql[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(sql)
+			}
+		case c == '\'' || c == '"' || c == '`':
+			j := strings.IndexByte(sql[i+1:], c)
+			if j < 0 {
+				return
+			}
+			i += j + 2
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			depth--
+			i++
+		case isWordChar(rune(c)):
+			j := i
+			for j < len(sql) && isWordChar(rune(sql[j])) {
+				j++
+			}
+			if depth == 0 && !f(upperWord(sql[i:j])) {
+				return
+			}
+			i = j
+		default:
+			i++
+		}
+	}
+}
+
+// upperWord returns w in upper case, w itself when it has no lower case letter.
+func upperWord(w string) string {
+	for i := 0; i < len(w); i++ {
+		if c := w[i]; c >= 'a' && c <= 'z' || c >= 0x80 {
+			return strings.ToUpper(w)
+		}
+	}
+	return w
+}
+
+// containsFold reports if substr, in upper case ASCII, is in s whatever the case of s.
+func containsFold(s, substr string) bool {
+	for i := 0; i+len(substr) <= len(s); i++ {
+		if strings.EqualFold(s[i:i+len(substr)], substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func isWordChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// sqlOperation classifies the statement for GormInfos.Operation.
+func sqlOperation(sql string) string {
+	switch sqlVerb(sql) {
+	case "SELECT", "SHOW", "VALUES", "TABLE":
+		return OperationSelect
+	case "INSERT", "REPLACE", "UPSERT":
+		return OperationInsert
+	case "UPDATE":
+		return OperationUpdate
+	case "DELETE":
+		return OperationDelete
+	case "CREATE", "ALTER", "DROP", "TRUNCATE", "RENAME", "COMMENT":
+		return OperationDDL
+	}
+	return OperationOther
+}
+
+// dangerousStatement returns why the statement can change or drop a whole table:
+// "UPDATE without WHERE", "DELETE without WHERE", "TRUNCATE" or "DROP". It is empty for the other statements.
+func dangerousStatement(sql string) string {
+	verb := sqlVerb(sql)
+	switch verb {
+	case "TRUNCATE", "DROP":
+		return verb
+	case "UPDATE", "DELETE":
+		where := false
+		eachSQLWord(strings.TrimLeft(sql, " \t\r\n("), func(w string) bool {
+			where = w == "WHERE"
+			return !where
+		})
+		if where {
+			return ""
+		}
+		return verb + " without WHERE"
+	}
+	return ""
+}
+
+// isWrite reports statements that take row locks: data changes and SELECT ... FOR UPDATE/SHARE.
+func isWrite(sql string) bool {
+	switch sqlVerb(sql) {
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE", "UPSERT":
+		return true
+	case "SELECT":
+		return containsFold(sql, "FOR UPDATE") || containsFold(sql, "FOR SHARE") ||
+			containsFold(sql, "LOCK IN SHARE MODE")
+	}
+	return false
+}
+
+// fingerprint normalizes the statement so the executions of the same query shape are equal:
+// literals are replaced by ?, lists of them by (?...) and the whitespace is collapsed.
+// The result is interned, the same query shape doesn't allocate a new string at each execution.
+func fingerprint(sql string) string {
+	b := getBuffer()
+	defer putBuffer(b)
+	b.Grow(len(sql))
+
+	space := false
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			space = true
+			i++
+			continue
+		case c == '-' && strings.HasPrefix(sql[i:], "--"):
+			if j := strings.IndexByte(sql[i:], '\n'); j >= 0 {
+				i += j + 1
+			} else {
+				i = len(sql)
+			}
+			space = true
+			continue
+		case c == '/' && strings.HasPrefix(sql[i:], "/*"):
+			if j := strings.Index(sql[i+2:], "*/"); j >= 0 {
+				i += j + 4
+			} else {
+				i = len(sql)
+			}
+			space = true
+			continue
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+
+		if end := literalEnd(sql, i); end > i {
+			b.WriteByte('?')
+			i = end
+			continue
+		}
+
+		switch {
+		case c == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i + 1
+			for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+				j++
+			}
+			b.WriteByte('?')
+			i = j
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+
+	return fingerprints.intern(collapseLists(b.Bytes()))
+}
+
+// literalEnd returns the end of the string or number literal starting at i, or i when there is none.
+// The double quoted strings are literals when they are values, see doubleQuotedValue.
+func literalEnd(sql string, i int) int {
+	c := sql[i]
+	switch {
+	case c == '\'' || c == '"' && doubleQuotedValue(sql, i):
+		return quotedEnd(sql, i)
+	case c >= '0' && c <= '9' && !prevIsWord(sql, i):
+		j := i
+		for j < len(sql) && (sql[j] >= '0' && sql[j] <= '9' || sql[j] == '.') {
+			j++
+		}
+		return j
+	}
+	return i
+}
+
+// quotedEnd returns the end of the string quoted by sql[i], the quotes are escaped by \ or doubled.
+func quotedEnd(sql string, i int) int {
+	q := sql[i]
+	j := i + 1
+	for j < len(sql) {
+		if sql[j] == '\\' {
+			j += 2
+			continue
+		}
+		if sql[j] == q {
+			if j+1 < len(sql) && sql[j+1] == q {
+				j += 2
+				continue
+			}
+			break
+		}
+		j++
+	}
+	if j >= len(sql) {
+		return len(sql)
+	}
+	return j + 1
+}
+
+// doubleQuotedValue reports if the double quoted string starting at i is a value and not an identifier.
+// The sqlite and mysql dialects of gorm write the string values between double quotes and quote the identifiers
+// with backticks, postgres quotes the identifiers with double quotes. Without backticks in the statement
+// the string is a value when it is compared (=, <>, <, >, LIKE) and isn't qualified ("users"."id").
+func doubleQuotedValue(sql string, i int) bool {
+	end := quotedEnd(sql, i)
+	if i > 0 && sql[i-1] == '.' || end < len(sql) && sql[end] == '.' {
+		return false
+	}
+	if backtickQuoted(sql) {
+		return true
+	}
+
+	before := strings.TrimRight(sql[:i], " \t\r\n")
+	if before == "" {
+		return false
+	}
+	switch before[len(before)-1] {
+	case '=', '<', '>':
+		return true
+	}
+	return len(before) >= 4 && strings.EqualFold(before[len(before)-4:], "like") && !prevIsWord(before, len(before)-4)
+}
+
+// backtickQuoted reports if the statement quotes its identifiers with backticks (sqlite, mysql),
+// the backticks inside the quoted strings, ex: a value of postgres, don't count.
+func backtickQuoted(sql string) bool {
+	for i := 0; i < len(sql); i++ {
+		switch sql[i] {
+		case '`':
+			return true
+		case '\'', '"':
+			i = quotedEnd(sql, i) - 1
+		}
+	}
+	return false
+}
+
+// stripLiterals replaces the string and number literals by ?, keeping the rest of the statement as is.
+// The string values between double quotes of the sqlite and mysql dialects are literals, see doubleQuotedValue.
+func stripLiterals(sql string) string {
+	var b strings.Builder
+	b.Grow(len(sql))
+
+	for i := 0; i < len(sql); {
+		if end := literalEnd(sql, i); end > i {
+			b.WriteByte('?')
+			i = end
+			continue
+		}
+		b.WriteByte(sql[i])
+		i++
+	}
+	return b.String()
+}
+
+func prevIsWord(sql string, i int) bool {
+	return i > 0 && isWordChar(rune(sql[i-1]))
+}
+
+// collapseLists turns (?, ?, ?) into (?...) so IN clauses of any size share the fingerprint. b is changed in place.
+// The parentheses that aren't only placeholders, ex: COALESCE(?, ?, col), are kept.
+func collapseLists(b []byte) []byte {
+	const list = "(?...)"
+	for from := 0; ; {
+		i := indexList(b[from:])
+		if i < 0 {
+			return b
+		}
+		i += from
+		j := i + 1
+		for j < len(b) && (b[j] == '?' || b[j] == ',' || b[j] == ' ') {
+			j++
+		}
+		if j >= len(b) || b[j] != ')' {
+			from = i + 1
+			continue
+		}
+		// The list is replaced by (?...), the rest is moved after it.
+		tail := len(b) - (j + 1)
+		n := i + len(list) + tail
+		for len(b) < n {
+			b = append(b, 0)
+		}
+		copy(b[i+len(list):n], b[j+1:j+1+tail])
+		copy(b[i:], list)
+		b = b[:n]
+		from = i + len(list)
+	}
+}
+
+// indexList returns the index of the first "(?, ?" or "(?,?" in b, -1 without them.
+func indexList(b []byte) int {
+	for i := bytes.IndexByte(b, '('); i >= 0; {
+		if bytes.HasPrefix(b[i:], []byte("(?, ?")) || bytes.HasPrefix(b[i:], []byte("(?,?")) {
+			return i
+		}
+		next := bytes.IndexByte(b[i+1:], '(')
+		if next < 0 {
+			return -1
+		}
+		i += next + 1
+	}
+	return -1
+}
+
+// sqlTables returns the tables following FROM, JOIN, INTO, UPDATE and TABLE in the statement,
+// in order of appearance and without duplicates. Quotes and schema prefixes are kept out.
+// The FROM of the functions (EXTRACT(YEAR FROM col), TRIM(x FROM col)) and the UPDATE of the upserts
+// (ON DUPLICATE KEY UPDATE, DO UPDATE) and of the locks (FOR UPDATE) are not followed by a table.
+func sqlTables(sql string) []string {
+	var (
+		tables []string
+		depth  int
+		// queries has a bit per open parenthesis (the first 64), set when it is a subquery: its first
+		// word is SELECT or WITH.
+		queries uint64
+		// opened is set after an opening parenthesis, until its first word.
+		opened bool
+		// table is set when the next word is a table name.
+		table bool
+		prev  string
+	)
+	eachIdentifier(sql, func(w string) {
+		switch w {
+		case "(":
+			depth++
+			opened, table, prev = true, false, w
+			if depth <= 64 {
+				queries &^= 1 << (depth - 1)
+			}
+			return
+		case ")":
+			if depth > 0 {
+				depth--
+			}
+			opened, table, prev = false, false, w
+			return
+		}
+
+		if opened {
+			opened = false
+			if depth <= 64 && (strings.EqualFold(w, "SELECT") || strings.EqualFold(w, "WITH")) {
+				queries |= 1 << (depth - 1)
+			}
+		}
+		if table {
+			table = false
+			if w != "" && !isKeyword(w, "SELECT", "IF", "ONLY", "LATERAL", "SET") && !contains(tables, w) {
+				tables = append(tables, w)
+			}
+		}
+
+		switch {
+		case strings.EqualFold(w, "FROM"):
+			table = depth == 0 || depth > 64 || queries&(1<<(depth-1)) != 0
+		case strings.EqualFold(w, "UPDATE"):
+			table = !isKeyword(prev, "KEY", "DO", "FOR")
+		case isKeyword(w, "JOIN", "INTO", "TABLE"):
+			table = true
+		}
+		prev = w
+	})
+	return tables
+}
+
+// isKeyword reports if w is one of the keywords, whatever its case.
+func isKeyword(w string, keywords ...string) bool {
+	for _, k := range keywords {
+		if strings.EqualFold(w, k) {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// eachIdentifier calls f with the words of the statement, the string literals are skipped and the parentheses
+// are words. The qualified names are given by their last part without quotes: `shop`.`users` is users.
+// The words are substrings of sql, nothing is allocated.
+func eachIdentifier(sql string, f func(w string)) {
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == '\'':
+			if j := strings.IndexByte(sql[i+1:], '\''); j >= 0 {
+				i += j + 2
+			} else {
+				i = len(sql)
+			}
+		case c == '"' || c == '`' || c == '.' || isWordChar(rune(c)):
+			last := ""
+			for i < len(sql) {
+				c := sql[i]
+				if c == '"' || c == '`' {
+					j := strings.IndexByte(sql[i+1:], c)
+					if j < 0 {
+						i = len(sql)
+						break
+					}
+					last = sql[i+1 : i+1+j]
+					i += j + 2
+					continue
+				}
+				if c == '.' {
+					last = ""
+					i++
+					continue
+				}
+				if !isWordChar(rune(c)) {
+					break
+				}
+				j := i
+				for j < len(sql) && isWordChar(rune(sql[j])) {
+					j++
+				}
+				last = sql[i:j]
+				i = j
+			}
+			f(last)
+		case c == '(' || c == ')':
+			f(sql[i : i+1])
+			i++
+		default:
+			i++
+		}
+	}
+}
+
+// sqlToken is a token of a statement, the spaces are skipped.
+type sqlToken struct {
+	start, end int
+	// kind is 'i' for the identifiers and keywords, 'l' for the literals and 'p' for the rest.
+	kind byte
+	// name is the lower case identifier without quotes nor qualifier.
+	name string
+}
+
+func tokenize(sql string) []sqlToken {
+	var tokens []sqlToken
+	eachToken(sql, func(t sqlToken) {
+		if t.kind == 'i' {
+			name := strings.ToLower(sql[t.start:t.end])
+			if dot := strings.LastIndexByte(name, '.'); dot >= 0 {
+				name = name[dot+1:]
+			}
+			t.name = strings.Trim(name, "\"`")
+		}
+		tokens = append(tokens, t)
+	})
+	return tokens
+}
+
+// eachToken calls f with the tokens of sql in order, without their name, so the callers that only need the
+// positions don't allocate.
+func eachToken(sql string, f func(t sqlToken)) {
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case literalEnd(sql, i) > i:
+			end := literalEnd(sql, i)
+			f(sqlToken{start: i, end: end, kind: 'l'})
+			i = end
+		case c == '"' || c == '`' || c == '.' || isWordChar(rune(c)):
+			j := i
+			for j < len(sql) {
+				if sql[j] == '"' || sql[j] == '`' {
+					k := strings.IndexByte(sql[j+1:], sql[j])
+					if k < 0 {
+						j = len(sql)
+						break
+					}
+					j += k + 2
+					continue
+				}
+				if sql[j] != '.' && !isWordChar(rune(sql[j])) {
+					break
+				}
+				j++
+			}
+			f(sqlToken{start: i, end: j, kind: 'i'})
+			i = j
+		default:
+			f(sqlToken{start: i, end: i + 1, kind: 'p'})
+			i++
+		}
+	}
+}
+
+// clauseKeywords start a new line in prettySQL.
+var clauseKeywords = map[string]bool{
+	"select": true, "from": true, "where": true, "group": true, "order": true, "having": true,
+	"limit": true, "offset": true, "set": true, "values": true, "union": true, "returning": true,
+	"join": true, "left": true, "right": true, "inner": true, "full": true, "cross": true,
+	"on": true, "and": true, "or": true,
+}
+
+// prettySQL breaks the statement before its clauses, indenting the subqueries and the AND / OR conditions.
+// The string literals and quoted identifiers are kept as is.
+func prettySQL(sql string) string {
+	tokens := tokenize(sql)
+
+	var b strings.Builder
+	b.Grow(len(sql) + len(sql)/4)
+
+	depth, prev, between := 0, "", false
+	for i, t := range tokens {
+		text := sql[t.start:t.end]
+		word := ""
+		if t.kind == 'i' {
+			word = strings.ToLower(text)
+		}
+
+		newLine := i > 0 && clauseKeywords[word]
+		switch word {
+		case "join":
+			switch prev {
+			case "left", "right", "inner", "outer", "full", "cross", "natural":
+				newLine = false
+			}
+		case "left", "right":
+			// the LEFT() and RIGHT() functions
+			newLine = newLine && !(i+1 < len(tokens) && sql[tokens[i+1].start] == '(')
+		case "between":
+			between = true
+		case "and":
+			// BETWEEN x AND y
+			newLine = newLine && !between
+			between = false
+		}
+
+		switch {
+		case newLine:
+			b.WriteByte('\n')
+			indent := depth * 4
+			if word == "and" || word == "or" || word == "on" {
+				indent += 2
+			}
+			b.WriteString(strings.Repeat(" ", indent))
+		case i > 0 && tokens[i-1].end < t.start:
+			b.WriteByte(' ')
+		}
+		b.WriteString(text)
+
+		if t.kind == 'p' {
+			switch text {
+			case "(":
+				depth++
+			case ")":
+				if depth > 0 {
+					depth--
+				}
+			}
+		}
+		prev = word
+	}
+	return b.String()
+}
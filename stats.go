@@ -0,0 +1,199 @@
+package cgLogger
+
+import (
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the cumulative counters of the traced queries since Since.
+type Stats struct {
+	Since   time.Time
+	Queries int64
+	// Traces written by level.
+	InfoTraces  int64
+	WarnTraces  int64
+	ErrorTraces int64
+	// SlowQueries took more than the SlowThreshold.
+	SlowQueries int64
+	// Errors doesn't count ErrRecordNotFound, see NotFound.
+	Errors   int64
+	NotFound int64
+
+	MinDuration time.Duration
+	MaxDuration time.Duration
+	AvgDuration time.Duration
+
+	// Tenants are the counters by tenant, see Tenant.
+	Tenants map[string]TenantStats
+}
+
+// TenantStats are the counters of the queries of a tenant.
+type TenantStats struct {
+	Queries     int64
+	SlowQueries int64
+	Errors      int64
+	Duration    time.Duration
+}
+
+// stats are the running counters, updated atomically.
+type stats struct {
+	since    time.Time
+	queries  int64
+	errors   int64
+	notFound int64
+	slow     int64
+	nanos    int64
+	min      int64
+	max      int64
+
+	infoTraces  int64
+	warnTraces  int64
+	errorTraces int64
+
+	// tenants holds a *tenantStats by tenant.
+	tenants sync.Map
+}
+
+type tenantStats struct {
+	queries int64
+	slow    int64
+	errors  int64
+	nanos   int64
+}
+
+func (s *stats) record(elapsed time.Duration, slow bool, err error, tenant string) {
+	atomic.AddInt64(&s.queries, 1)
+	atomic.AddInt64(&s.nanos, elapsed.Nanoseconds())
+	if slow {
+		atomic.AddInt64(&s.slow, 1)
+	}
+	failed := err != nil && !isNotFound(err)
+	if err != nil {
+		if failed {
+			atomic.AddInt64(&s.errors, 1)
+		} else {
+			atomic.AddInt64(&s.notFound, 1)
+		}
+	}
+
+	if tenant != "" {
+		v, ok := s.tenants.Load(tenant)
+		if !ok {
+			v, _ = s.tenants.LoadOrStore(tenant, &tenantStats{})
+		}
+		t := v.(*tenantStats)
+		atomic.AddInt64(&t.queries, 1)
+		atomic.AddInt64(&t.nanos, elapsed.Nanoseconds())
+		if slow {
+			atomic.AddInt64(&t.slow, 1)
+		}
+		if failed {
+			atomic.AddInt64(&t.errors, 1)
+		}
+	}
+
+	d := elapsed.Nanoseconds()
+	for {
+		min := atomic.LoadInt64(&s.min)
+		if (min != 0 && min <= d) || atomic.CompareAndSwapInt64(&s.min, min, d) {
+			break
+		}
+	}
+	for {
+		max := atomic.LoadInt64(&s.max)
+		if max >= d || atomic.CompareAndSwapInt64(&s.max, max, d) {
+			break
+		}
+	}
+}
+
+// written counts the trace lines by level.
+func (s *stats) written(level string) {
+	switch level {
+	case LevelInfo:
+		atomic.AddInt64(&s.infoTraces, 1)
+	case LevelWarn:
+		atomic.AddInt64(&s.warnTraces, 1)
+	case LevelError:
+		atomic.AddInt64(&s.errorTraces, 1)
+	}
+}
+
+func (s *stats) snapshot() Stats {
+	out := Stats{
+		Since:       s.since,
+		Queries:     atomic.LoadInt64(&s.queries),
+		InfoTraces:  atomic.LoadInt64(&s.infoTraces),
+		WarnTraces:  atomic.LoadInt64(&s.warnTraces),
+		ErrorTraces: atomic.LoadInt64(&s.errorTraces),
+		SlowQueries: atomic.LoadInt64(&s.slow),
+		Errors:      atomic.LoadInt64(&s.errors),
+		NotFound:    atomic.LoadInt64(&s.notFound),
+		MinDuration: time.Duration(atomic.LoadInt64(&s.min)),
+		MaxDuration: time.Duration(atomic.LoadInt64(&s.max)),
+	}
+	if out.Queries > 0 {
+		out.AvgDuration = time.Duration(atomic.LoadInt64(&s.nanos) / out.Queries)
+	}
+	s.tenants.Range(func(key, value interface{}) bool {
+		if out.Tenants == nil {
+			out.Tenants = make(map[string]TenantStats)
+		}
+		t := value.(*tenantStats)
+		out.Tenants[key.(string)] = TenantStats{
+			Queries:     atomic.LoadInt64(&t.queries),
+			SlowQueries: atomic.LoadInt64(&t.slow),
+			Errors:      atomic.LoadInt64(&t.errors),
+			Duration:    time.Duration(atomic.LoadInt64(&t.nanos)),
+		}
+		return true
+	})
+	return out
+}
+
+// statsHolder keeps the current stats so they can be reset without locking the queries.
+// It is shared between the loggers derived with LogMode.
+type statsHolder struct {
+	current atomic.Value
+}
+
+func newStatsHolder() *statsHolder {
+	h := &statsHolder{}
+	h.reset()
+	return h
+}
+
+func (h *statsHolder) get() *stats {
+	return h.current.Load().(*stats)
+}
+
+func (h *statsHolder) reset() {
+	h.current.Store(&stats{since: time.Now()})
+}
+
+// Stats returns the counters of the queries since the logger creation or the last ResetStats.
+func (l *customLogger) Stats() Stats {
+	return l.stats.get().snapshot()
+}
+
+// ResetStats starts the counters over.
+func (l *customLogger) ResetStats() {
+	l.stats.reset()
+}
+
+// PublishExpvar publishes the counters (queries, errors, slow_queries, avg_latency_ms) under name in expvar,
+// so they are served in /debug/vars. As expvar.Publish it panics if the name is already used.
+func (l *customLogger) PublishExpvar(name string) CInterface {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		s := l.Stats()
+		return map[string]interface{}{
+			"queries":        s.Queries,
+			"errors":         s.Errors,
+			"slow_queries":   s.SlowQueries,
+			"avg_latency_ms": float64(s.AvgDuration.Nanoseconds()) / 1e6,
+		}
+	}))
+	return l
+}
@@ -0,0 +1,99 @@
+package cgLogger
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// StatsDConfig configures a StatsDSink, only Address is required.
+type StatsDConfig struct {
+	// Address of the agent, ex: 127.0.0.1:8125
+	Address string
+	// Prefix of the metrics, defaults to "gorm": gorm.query.duration, gorm.query.count, gorm.query.errors
+	Prefix string
+	// DogStatsD writes the tags (|#k:v), plain StatsD has no tags.
+	DogStatsD bool
+	// Service is sent as the service tag.
+	Service string
+	// Tags are sent with every metric, besides service, table and operation.
+	Tags map[string]string
+}
+
+// StatsDSink emits a timing and a count for every trace (and a count for errors) to StatsD or DogStatsD,
+// tagged by service, table and operation when DogStatsD is set:
+//
+//	sink, err := cgLogger.NewStatsDSink(cgLogger.StatsDConfig{Address: "127.0.0.1:8125", DogStatsD: true, Service: "orders"})
+//	logger.AlwaysTrigger(sink.Trigger)
+type StatsDSink struct {
+	config StatsDConfig
+	conn   net.Conn
+	tags   string
+}
+
+// NewStatsDSink opens the UDP socket, the packets are sent without waiting for the agent.
+func NewStatsDSink(config StatsDConfig) (*StatsDSink, error) {
+	if config.Prefix == "" {
+		config.Prefix = "gorm"
+	}
+	conn, err := net.Dial("udp", config.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	constant := make([]string, 0, len(config.Tags)+1)
+	if config.Service != "" {
+		constant = append(constant, "service:"+config.Service)
+	}
+	for k, v := range config.Tags {
+		constant = append(constant, k+":"+v)
+	}
+	sort.Strings(constant)
+
+	return &StatsDSink{config: config, conn: conn, tags: strings.Join(constant, ",")}, nil
+}
+
+// Trigger sends the metrics of the query, it is meant to be passed to AlwaysTrigger.
+func (s *StatsDSink) Trigger(g GormInfos) {
+	tags := s.queryTags(g)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s.query.duration:%.3f|ms%s\n", s.config.Prefix, g.QueryDuration, tags)
+	fmt.Fprintf(&b, "%s.query.count:1|c%s", s.config.Prefix, tags)
+	if g.Err != nil && !isNotFound(g.Err) {
+		fmt.Fprintf(&b, "\n%s.query.errors:1|c%s", s.config.Prefix, tags)
+	}
+
+	// errors are ignored as the agent may be down, the metrics are lost as with any UDP client.
+	_, _ = s.conn.Write(b.Bytes())
+}
+
+// queryTags returns the DogStatsD tag suffix, empty for plain StatsD. The operation and table are the ones
+// of GormInfos, as in the other sinks.
+func (s *StatsDSink) queryTags(g GormInfos) string {
+	if !s.config.DogStatsD {
+		return ""
+	}
+
+	tags := make([]string, 0, 3)
+	if s.tags != "" {
+		tags = append(tags, s.tags)
+	}
+	if g.Operation != "" {
+		tags = append(tags, "operation:"+strings.ToLower(g.Operation))
+	}
+	if len(g.Tables) > 0 {
+		tags = append(tags, "table:"+g.Tables[0])
+	}
+	if len(tags) == 0 {
+		return ""
+	}
+	return "|#" + strings.Join(tags, ",")
+}
+
+// Close closes the socket.
+func (s *StatsDSink) Close() error {
+	return s.conn.Close()
+}
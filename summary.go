@@ -0,0 +1,155 @@
+package cgLogger
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyBuckets are the upper bounds of the histogram buckets, the last bucket has no bound.
+var latencyBuckets = []time.Duration{
+	time.Millisecond,
+	2 * time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	200 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2 * time.Second,
+	5 * time.Second,
+}
+
+// histogramWidth is the width of the longest bar of the histogram.
+const histogramWidth = 40
+
+// queryLatency aggregates the executions of a fingerprint.
+type queryLatency struct {
+	fingerprint string
+	count       int64
+	total       time.Duration
+	min, max    time.Duration
+	buckets     []int64
+}
+
+func (q *queryLatency) add(d time.Duration) {
+	if q.count == 0 || d < q.min {
+		q.min = d
+	}
+	if d > q.max {
+		q.max = d
+	}
+	q.count++
+	q.total += d
+
+	i := sort.Search(len(latencyBuckets), func(i int) bool { return d <= latencyBuckets[i] })
+	q.buckets[i]++
+}
+
+// summary collects the latencies by fingerprint while the summary mode is on,
+// it is shared between the loggers derived with LogMode.
+type summary struct {
+	enabled int32
+	mu      sync.Mutex
+	queries map[string]*queryLatency
+}
+
+func newSummary() *summary {
+	return &summary{queries: map[string]*queryLatency{}}
+}
+
+func (s *summary) on() bool {
+	return atomic.LoadInt32(&s.enabled) == 1
+}
+
+func (s *summary) toggle(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&s.enabled, v)
+}
+
+func (s *summary) record(fp string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := s.queries[fp]
+	if q == nil {
+		q = &queryLatency{fingerprint: fp, buckets: make([]int64, len(latencyBuckets)+1)}
+		s.queries[fp] = q
+	}
+	q.add(d)
+}
+
+// snapshot returns a copy of the aggregations, the most expensive (total time) first.
+func (s *summary) snapshot() []queryLatency {
+	s.mu.Lock()
+	out := make([]queryLatency, 0, len(s.queries))
+	for _, q := range s.queries {
+		cp := *q
+		cp.buckets = append([]int64(nil), q.buckets...)
+		out = append(out, cp)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].total != out[j].total {
+			return out[i].total > out[j].total
+		}
+		return out[i].fingerprint < out[j].fingerprint
+	})
+	return out
+}
+
+// print writes the report, one block per fingerprint with its latency histogram.
+func (s *summary) print(w io.Writer) error {
+	for _, q := range s.snapshot() {
+		avg := q.total / time.Duration(q.count)
+		_, err := fmt.Fprintf(w, "%s\n  count=%d total=%v min=%v avg=%v max=%v\n", q.fingerprint, q.count, q.total, q.min, avg, q.max)
+		if err != nil {
+			return err
+		}
+		if err := writeHistogram(w, q.buckets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeHistogram writes the non empty buckets as bars scaled to the largest one.
+func writeHistogram(w io.Writer, buckets []int64) error {
+	var largest int64
+	for _, n := range buckets {
+		if n > largest {
+			largest = n
+		}
+	}
+
+	for i, n := range buckets {
+		if n == 0 {
+			continue
+		}
+		bar := int(n * histogramWidth / largest)
+		if bar == 0 {
+			bar = 1
+		}
+		if _, err := fmt.Fprintf(w, "  %10s |%-*s %d\n", bucketLabel(i), histogramWidth, strings.Repeat("#", bar), n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func bucketLabel(i int) string {
+	if i == len(latencyBuckets) {
+		return "> " + latencyBuckets[i-1].String()
+	}
+	return "<= " + latencyBuckets[i].String()
+}
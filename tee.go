@@ -0,0 +1,84 @@
+package cgLogger
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// TeeOutput is a destination of a Tee with its own format and colors.
+type TeeOutput struct {
+	Writer   Writer
+	Format   Format
+	Colorful bool
+}
+
+// Tee returns a Writer sending every line to all the outputs, each one formatted with the configuration
+// of the logger (TimeFormat, UTC, DurationFormat, Theme, Templates...) and its own Format and Colorful:
+//
+//	logger := cgLogger.New(cgLogger.Tee(
+//		cgLogger.TeeOutput{Writer: log.New(os.Stdout, "\r\n", log.LstdFlags), Colorful: true},
+//		cgLogger.TeeOutput{Writer: file, Format: cgLogger.JSONFormat},
+//	), config)
+func Tee(outputs ...TeeOutput) EntryWriter {
+	t := &tee{outputs: outputs, loggers: make([]*customLogger, len(outputs))}
+	for i, o := range outputs {
+		t.loggers[i] = New(o.Writer, Config{Format: o.Format, Colorful: o.Colorful}).(*customLogger)
+	}
+	return t
+}
+
+// tee holds a logger per output, only used to format the entries.
+type tee struct {
+	outputs []TeeOutput
+	loggers []*customLogger
+
+	mu sync.Mutex
+	// parent is the settings of the logger the outputs were configured from.
+	parent *settingsSnapshot
+}
+
+// Printf sends the lines that don't come as entries as they are.
+func (t *tee) Printf(format string, data ...interface{}) {
+	line := fmt.Sprintf(format, data...)
+	for _, l := range t.loggers {
+		l.Printf("%s", line)
+	}
+}
+
+// WriteEntry formats e for each output.
+func (t *tee) WriteEntry(e Entry) {
+	for _, l := range t.loggers {
+		l.write(e)
+	}
+}
+
+// writeEntryFrom formats e for each output with the configuration of the logger writing it,
+// the outputs are reconfigured when it changed.
+func (t *tee) writeEntryFrom(parent *settingsSnapshot, e Entry) {
+	t.mu.Lock()
+	if parent != t.parent {
+		t.parent = parent
+		for i, o := range t.outputs {
+			config := parent.config
+			config.Format, config.Colorful = o.Format, o.Colorful
+			t.loggers[i].settings.reload(config)
+		}
+	}
+	t.mu.Unlock()
+
+	t.WriteEntry(e)
+}
+
+// Close closes the outputs that are an io.Closer, the first error is returned.
+func (t *tee) Close() error {
+	var first error
+	for _, l := range t.loggers {
+		if c, ok := l.Writer.(io.Closer); ok {
+			if err := c.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
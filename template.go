@@ -0,0 +1,65 @@
+package cgLogger
+
+import (
+	"bytes"
+	"text/template"
+)
+
+// Templates replace the layouts of the text format, see Config.Templates. The nil ones keep the default layout.
+// They are executed with a TemplateData and their output is written as is, without the colors and fields
+// added by the default layouts:
+//
+//	Templates{
+//		Trace: template.Must(template.New("trace").Parse(
+//			`{{.Time.Format "15:04:05"}} {{.Level}} {{.Location}} dur={{printf "%.3f" .Infos.QueryDuration}}ms rows={{.Rows}} {{.SQL}}`)),
+//		Info: template.Must(template.New("info").Parse(`{{.Time.Format "15:04:05"}} INFO {{.Message}}`)),
+//	}
+type Templates struct {
+	// Info is also used for the Debugf messages.
+	Info  *template.Template
+	Warn  *template.Template
+	Error *template.Template
+	// Trace is used for the traces of all the levels, .Level and .Critical tell them apart.
+	Trace *template.Template
+}
+
+// TemplateData is given to the Templates.
+type TemplateData struct {
+	Entry
+	// Infos are the ones given to the triggers, they are zero for the messages.
+	Infos GormInfos
+}
+
+// template returns the template of the entry, nil for the default layout.
+func (t *Templates) template(e Entry) *template.Template {
+	if e.Trace {
+		return t.Trace
+	}
+	switch e.Level {
+	case LevelWarn:
+		return t.Warn
+	case LevelError:
+		return t.Error
+	}
+	return t.Info
+}
+
+// writeTemplate writes the entry with its template, it returns false when the default layout must be used:
+// without template or when the template fails, the error is then added to the fields of the entry.
+func writeTemplate(b *bytes.Buffer, t *Templates, e *Entry) bool {
+	tmpl := t.template(*e)
+	if tmpl == nil {
+		return false
+	}
+
+	data := TemplateData{Entry: *e}
+	if e.infos != nil {
+		data.Infos = *e.infos
+	}
+	if err := tmpl.Execute(b, data); err != nil {
+		b.Reset()
+		e.Fields = append(e.Fields[:len(e.Fields):len(e.Fields)], Field{Key: "template_error", Value: err})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package cgLogger
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Theme are the colors of the text lines with Colorful, ANSI escape codes like the Red or BlueBold constants.
+// An empty color writes the part without color. The Config without Theme keeps the colors of gorm.
+type Theme struct {
+	// Debug, Info, Warn and Error color the caller and the level of the lines,
+	// the Warn and Error ones also the SLOW SQL and error messages of the traces.
+	Debug, Info, Warn, Error string
+	// Duration and Rows color the [duration] and [rows:n] of the traces.
+	Duration, Rows string
+	// SQL is the color of the statements, their keywords, quoted identifiers and literals are highlighted
+	// with Keyword, Identifier and Literal.
+	SQL, Keyword, Identifier, Literal string
+}
+
+var (
+	// LightTheme avoids the yellow and cyan of the default colors, unreadable on a light background.
+	LightTheme = Theme{
+		Debug: Blue, Info: Green, Warn: Magenta, Error: RedBold,
+		Duration: Magenta, Rows: Blue,
+		Keyword: BlueBold, Identifier: Magenta, Literal: Green,
+	}
+	// MonochromeBoldTheme only writes in bold the levels and the keywords of the statements.
+	MonochromeBoldTheme = Theme{
+		Info: Bold, Warn: Bold, Error: Bold,
+		Keyword: Bold,
+	}
+)
+
+// highlight are the colors of the statements, the ones of gorm without Theme.
+func (t *Theme) highlight() (keyword, identifier, literal string) {
+	if t == nil {
+		return BlueBold, Cyan, Green
+	}
+	return t.Keyword, t.Identifier, t.Literal
+}
+
+// style returns the format strings of the text lines in the colors of the theme.
+func (t Theme) style() textStyle {
+	return textStyle{
+		debugStr:     paint(t.Debug, "%s\n") + paint(t.Debug, "[debug] "),
+		infoStr:      paint(t.Info, "%s\n") + paint(t.Info, "[info] "),
+		warnStr:      paint(t.Warn, "%s\n") + paint(t.Warn, "[warn] "),
+		errStr:       paint(t.Error, "%s\n") + paint(t.Error, "[error] "),
+		traceStr:     paint(t.Info, "%s\n") + t.trace(),
+		traceWarnStr: paint(t.Warn, "%s %s\n") + t.trace(),
+		traceErrStr:  paint(t.Error, "%s %s\n") + t.trace(),
+		traceCritStr: paint(t.Error, "%s %s\n") + t.trace(),
+	}
+}
+
+// trace is the end of the traces: duration, rows and statement.
+func (t Theme) trace() string {
+	return paint(t.Duration, "[%s]") + " " + paint(t.Rows, "[rows:%v]") + " " + paint(t.SQL, "%s")
+}
+
+func paint(color, s string) string {
+	if color == "" {
+		return s
+	}
+	return color + s + Reset
+}
+
+// themes are the built-in themes by name, for FileConfig.Theme.
+var themes = map[string]*Theme{
+	"default":         nil,
+	"light":           &LightTheme,
+	"monochrome-bold": &MonochromeBoldTheme,
+}
+
+// parseTheme returns the built-in theme of the name, nil for the default one.
+func parseTheme(name string) (*Theme, error) {
+	t, ok := themes[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown theme %q, expected default, light or monochrome-bold", name)
+	}
+	return t, nil
+}
@@ -0,0 +1,254 @@
+package cgLogger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// throttle allows at most n events per period, using fixed windows.
+// A zero n allows everything. It also holds a pause asked by a server (ex: Retry-After).
+type throttle struct {
+	n      int
+	period time.Duration
+
+	mu      sync.Mutex
+	start   time.Time
+	count   int
+	until   time.Time
+	dropped int64
+}
+
+func newThrottle(n int, period time.Duration) *throttle {
+	return &throttle{n: n, period: period}
+}
+
+// allow reports if an event can be sent at now, the refused ones are counted.
+func (t *throttle) allow(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Before(t.until) {
+		t.dropped++
+		return false
+	}
+	if t.n <= 0 {
+		return true
+	}
+
+	if now.Sub(t.start) >= t.period {
+		t.start = now
+		t.count = 0
+	}
+	if t.count >= t.n {
+		t.dropped++
+		return false
+	}
+	t.count++
+	return true
+}
+
+// pause refuses every event until the given time.
+func (t *throttle) pause(until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if until.After(t.until) {
+		t.until = until
+	}
+}
+
+// droppedCount returns how many events were refused.
+func (t *throttle) droppedCount() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.dropped
+}
+
+// fingerprintLimiter allows at most n lines per query fingerprint and period, see RateLimit.
+type fingerprintLimiter struct {
+	n      int
+	period time.Duration
+	// write writes the "suppressed N similar entries" lines of the windows expired without a new line.
+	write func(e Entry)
+
+	mu        sync.Mutex
+	windows   map[string]*limitWindow
+	scheduled bool
+}
+
+type limitWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+	// level and location are the ones of the last suppressed line.
+	level    string
+	location string
+}
+
+// maxLimitWindows is the size from which the expired windows are forgotten.
+const maxLimitWindows = 10000
+
+func newFingerprintLimiter(n int, period time.Duration, write func(e Entry)) *fingerprintLimiter {
+	return &fingerprintLimiter{n: n, period: period, write: write, windows: map[string]*limitWindow{}}
+}
+
+// allow reports if the line e of the fingerprint can be written. When a new window starts
+// it also returns how many lines were suppressed in the previous one.
+func (f *fingerprintLimiter) allow(fp string, e Entry) (ok bool, suppressed int) {
+	now := e.Time
+	var expired []Entry
+
+	f.mu.Lock()
+	w := f.windows[fp]
+	if w == nil {
+		if len(f.windows) >= maxLimitWindows {
+			expired = f.expire(now)
+		}
+		w = &limitWindow{start: now}
+		f.windows[fp] = w
+	}
+
+	if now.Sub(w.start) >= f.period {
+		suppressed = w.suppressed
+		*w = limitWindow{start: now}
+	}
+	if w.count >= f.n {
+		w.suppressed++
+		w.level, w.location = e.Level, e.Location
+		f.schedule()
+	} else {
+		w.count++
+		ok = true
+	}
+	f.mu.Unlock()
+
+	for _, s := range expired {
+		f.write(s)
+	}
+	return ok, suppressed
+}
+
+// schedule runs flush at the end of the period, so the suppressed lines are counted even when the fingerprint
+// isn't logged again. f.mu must be held.
+func (f *fingerprintLimiter) schedule() {
+	if !f.scheduled {
+		f.scheduled = true
+		time.AfterFunc(f.period, f.flush)
+	}
+}
+
+// flush writes the counts of the expired windows, the ones still running are flushed later.
+func (f *fingerprintLimiter) flush() {
+	f.mu.Lock()
+	f.scheduled = false
+	expired := f.expire(time.Now())
+	for _, w := range f.windows {
+		if w.suppressed > 0 {
+			f.schedule()
+			break
+		}
+	}
+	f.mu.Unlock()
+
+	for _, e := range expired {
+		f.write(e)
+	}
+}
+
+// expire forgets the expired windows and returns the lines counting their suppressed lines. f.mu must be held.
+func (f *fingerprintLimiter) expire(now time.Time) []Entry {
+	var entries []Entry
+	for fp, w := range f.windows {
+		if now.Sub(w.start) < f.period {
+			continue
+		}
+		if w.suppressed > 0 {
+			entries = append(entries, Entry{
+				Time:     now,
+				Level:    w.level,
+				Location: w.location,
+				Message:  fmt.Sprintf("suppressed %d similar entries", w.suppressed),
+				Fields:   []Field{{Key: "fingerprint", Value: fp}},
+			})
+		}
+		delete(f.windows, fp)
+	}
+	return entries
+}
+
+// errorDedup collapses the consecutive error lines of the same error and fingerprint, see DedupErrors.
+type errorDedup struct {
+	window time.Duration
+	// write writes the "last error repeated N times" line of a series expired without another error.
+	write func(e Entry)
+
+	mu        sync.Mutex
+	key       string
+	fp        string
+	last      Entry
+	start     time.Time
+	repeated  int
+	scheduled bool
+}
+
+// add reports if the error entry e must be written. When e ends a series of repeats, or the window of
+// the series expired, it also returns the line counting the repeats to write before it.
+func (d *errorDedup) add(fp string, e Entry) (write bool, repeats *Entry) {
+	key := e.Err.Error() + "\x00" + fp
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if key == d.key && e.Time.Sub(d.start) < d.window {
+		d.repeated++
+		d.last = e
+		if !d.scheduled {
+			d.scheduled = true
+			time.AfterFunc(d.start.Add(d.window).Sub(e.Time), d.expire)
+		}
+		return false, nil
+	}
+
+	if d.repeated > 0 {
+		r := d.repeats(e.Time)
+		repeats = &r
+	}
+	d.key, d.fp, d.last, d.start, d.repeated = key, fp, e, e.Time, 0
+	return true, repeats
+}
+
+// expire writes the count of the series when its window expired without another error.
+func (d *errorDedup) expire() {
+	d.mu.Lock()
+	d.scheduled = false
+	now := time.Now()
+	if d.repeated == 0 {
+		d.mu.Unlock()
+		return
+	}
+	if remaining := d.start.Add(d.window).Sub(now); remaining > 0 {
+		// a new series started since.
+		d.scheduled = true
+		time.AfterFunc(remaining, d.expire)
+		d.mu.Unlock()
+		return
+	}
+	repeats := d.repeats(now)
+	d.key, d.repeated = "", 0
+	d.mu.Unlock()
+
+	d.write(repeats)
+}
+
+// repeats is the line counting the repeats of the series. d.mu must be held.
+func (d *errorDedup) repeats(now time.Time) Entry {
+	return Entry{
+		Context:  d.last.Context,
+		Time:     now,
+		Level:    LevelError,
+		Location: d.last.Location,
+		Message:  fmt.Sprintf("last error repeated %d times: %v", d.repeated, d.last.Err),
+		Fields:   []Field{{Key: "fingerprint", Value: d.fp}},
+	}
+}
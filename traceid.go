@@ -0,0 +1,98 @@
+package cgLogger
+
+import (
+	"context"
+	"fmt"
+)
+
+// ContextField reads a field from the context, ex: the user or job ID. An empty key or a nil value is skipped.
+type ContextField func(ctx context.Context) (key string, value interface{})
+
+// contextIDs are the IDs read from the context by the TraceIDs, RequestID and Tenant extractors,
+// and the fields of the ContextFields.
+type contextIDs struct {
+	traceID, spanID, requestID, tenant string
+	fields                             []Field
+}
+
+// contextFields returns the IDs read from ctx by the extractors and their fields (trace_id, span_id, request_id,
+// tenant), followed by the ones of the ContextFields.
+func (l customLogger) contextFields(ctx context.Context) (ids contextIDs, fields []Field) {
+	if ctx == nil {
+		return ids, nil
+	}
+
+	if l.traceIDs != nil {
+		ids.traceID, ids.spanID = l.traceIDs(ctx)
+	}
+	if l.requestID != nil {
+		ids.requestID = l.requestID(ctx)
+	}
+	if l.tenant != nil {
+		ids.tenant = l.tenant(ctx)
+	}
+
+	if ids.traceID != "" {
+		fields = append(fields, Field{Key: "trace_id", Value: ids.traceID})
+	}
+	if ids.spanID != "" {
+		fields = append(fields, Field{Key: "span_id", Value: ids.spanID})
+	}
+	if ids.requestID != "" {
+		fields = append(fields, Field{Key: "request_id", Value: ids.requestID})
+	}
+	if ids.tenant != "" {
+		fields = append(fields, Field{Key: "tenant", Value: ids.tenant})
+	}
+	for _, f := range l.contextExtractors {
+		if key, value := f(ctx); key != "" && value != nil {
+			ids.fields = append(ids.fields, Field{Key: key, Value: value})
+		}
+	}
+	return ids, append(fields, ids.fields...)
+}
+
+// ContextFields adds extractors of fields from the context, they are written in every line and
+// set in GormInfos.Fields:
+//
+//	logger.ContextFields(func(ctx context.Context) (string, interface{}) {
+//		return "user_id", auth.UserID(ctx)
+//	})
+func (l *customLogger) ContextFields(extractors ...ContextField) CInterface {
+	l.contextExtractors = append(append([]ContextField(nil), l.contextExtractors...), extractors...)
+	return l
+}
+
+// RequestID sets how the request (or correlation, tenant...) ID is read from the context, it is written in every line
+// (request_id) and set in GormInfos.RequestID. ContextValue reads it from a context key:
+//
+//	logger.RequestID(cgLogger.ContextValue(middleware.RequestIDKey))
+func (l *customLogger) RequestID(f func(ctx context.Context) string) CInterface {
+	l.requestID = f
+	return l
+}
+
+// Tenant sets the context key of the tenant, its value is written in every line (tenant), set in GormInfos.Tenant
+// and the queries are counted by tenant in Stats.Tenants:
+//
+//	logger.Tenant(tenantKey{})
+//	db.WithContext(context.WithValue(ctx, tenantKey{}, "acme")).Find(&orders)
+func (l *customLogger) Tenant(key interface{}) CInterface {
+	l.tenant = ContextValue(key)
+	return l
+}
+
+// ContextValue returns an extractor of the value of key in the context, formatted with %v.
+// It is empty when the context doesn't have the key.
+func ContextValue(key interface{}) func(ctx context.Context) string {
+	return func(ctx context.Context) string {
+		switch v := ctx.Value(key).(type) {
+		case nil:
+			return ""
+		case string:
+			return v
+		default:
+			return fmt.Sprint(v)
+		}
+	}
+}
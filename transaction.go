@@ -0,0 +1,45 @@
+package cgLogger
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type txKey struct{}
+
+// txState is stored in the context of a transaction to know if it already holds locks.
+type txState struct {
+	wrote int32
+}
+
+// TxContext marks ctx as the context of a transaction, it is required by ReadInWriteTxTrigger:
+//
+//	db.WithContext(cgLogger.TxContext(ctx)).Transaction(func(tx *gorm.DB) error { ... })
+func TxContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, txKey{}, &txState{})
+}
+
+func txFromContext(ctx context.Context) *txState {
+	if ctx == nil {
+		return nil
+	}
+	tx, _ := ctx.Value(txKey{}).(*txState)
+	return tx
+}
+
+// readInWriteTx reports if sql is a read that took at least min inside a transaction
+// that already wrote, marking the transaction when sql is a write.
+func readInWriteTx(ctx context.Context, sql string, elapsed, min time.Duration) bool {
+	tx := txFromContext(ctx)
+	if tx == nil {
+		return false
+	}
+
+	if isWrite(sql) {
+		atomic.StoreInt32(&tx.wrote, 1)
+		return false
+	}
+
+	return atomic.LoadInt32(&tx.wrote) == 1 && elapsed >= min && sqlVerb(sql) == "SELECT"
+}
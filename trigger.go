@@ -0,0 +1,308 @@
+package cgLogger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	lg "gorm.io/gorm/logger"
+)
+
+// Names of the triggers set by AlwaysTrigger, SlowTrigger, CriticalTrigger, ErrorTrigger, OnDuplicateKey,
+// OnConstraintViolation, ReadInWriteTxTrigger, DangerTrigger, RowsTrigger, NPlusOneTrigger, DuplicateQueryTrigger, CanceledTrigger, DeadlineTrigger, ErrorRateTrigger and OnErrorClass (errorClassName + class), they can be removed with RemoveTrigger.
+const (
+	alwaysName              = "cglogger.always"
+	slowName                = "cglogger.slow"
+	criticalName            = "cglogger.critical"
+	errorName               = "cglogger.error"
+	duplicateKeyName        = "cglogger.duplicate_key"
+	constraintViolationName = "cglogger.constraint_violation"
+	readInWriteTxName       = "cglogger.read_in_write_tx"
+	dangerName              = "cglogger.danger"
+	rowsName                = "cglogger.rows"
+	nPlusOneName            = "cglogger.n_plus_one"
+	duplicateQueryName      = "cglogger.duplicate_query"
+	canceledName            = "cglogger.canceled"
+	deadlineName            = "cglogger.deadline"
+	errorRateName           = "cglogger.error_rate"
+	errorClassName          = "cglogger.error_class."
+)
+
+// trigger is a named callback invoked for the queries it matches.
+type trigger struct {
+	name string
+	// alerting triggers are suppressed during maintenance, only the always triggers are not.
+	alerting bool
+	// match is nil for the always triggers.
+	match func(e triggerEvent) bool
+	f     func(g GormInfos)
+	// line writes the line of the built-in triggers (DANGEROUS SQL, N+1 QUERY...) with the logger running them,
+	// before f and out of the worker pool so it isn't dropped with the call of f.
+	line func(l customLogger, g GormInfos)
+}
+
+// triggerEvent is what the triggers match against.
+type triggerEvent struct {
+	ctx              context.Context
+	elapsed          time.Duration
+	infos            GormInfos
+	considerNotFound bool
+}
+
+func alwaysTrigger(name string, f func(g GormInfos)) trigger {
+	return trigger{name: name, f: f}
+}
+
+func slowTrigger(name string, f func(g GormInfos), duration time.Duration) trigger {
+	return trigger{name: name, alerting: true, f: f, match: func(e triggerEvent) bool {
+		return duration != 0 && e.elapsed > duration
+	}}
+}
+
+func errorTrigger(name string, f func(g GormInfos)) trigger {
+	return trigger{name: name, alerting: true, f: f, match: func(e triggerEvent) bool {
+		err := e.infos.Err
+		return err != nil && (!errors.Is(err, ErrRecordNotFound) || e.considerNotFound)
+	}}
+}
+
+// triggerState is the trigger configuration, it is never modified once stored: the setters store a copy.
+type triggerState struct {
+	triggers                    []trigger
+	considerRecordNotFoundError bool
+	triggerError                func(g GormInfos, err error)
+	triggerTimeout              time.Duration
+	requestEnd                  func(ctx context.Context, s RequestSummary)
+}
+
+// triggerRegistry holds the trigger configuration shared by the loggers derived with LogMode.
+// The queries read it without locking, the setters swap it, so it can be changed after
+// the logger is handed to gorm.
+type triggerRegistry struct {
+	mu      sync.Mutex
+	current atomic.Value
+}
+
+func newTriggerRegistry() *triggerRegistry {
+	r := &triggerRegistry{}
+	r.current.Store(&triggerState{})
+	return r
+}
+
+func (r *triggerRegistry) load() *triggerState {
+	return r.current.Load().(*triggerState)
+}
+
+// update applies f to a copy of the state and stores it.
+func (r *triggerRegistry) update(f func(s *triggerState)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s := *r.load()
+	s.triggers = append([]trigger(nil), s.triggers...)
+	f(&s)
+	r.current.Store(&s)
+}
+
+// fork returns a registry starting from the current state, the states are immutable so nothing is copied
+// until one of the registries is changed.
+func (r *triggerRegistry) fork() *triggerRegistry {
+	f := &triggerRegistry{}
+	f.current.Store(r.load())
+	return f
+}
+
+// execution returns the trigger configuration in effect.
+func (l customLogger) execution() *triggerState {
+	return l.registry.load()
+}
+
+// setTrigger replaces the trigger with the same name or appends t, a nil f removes it.
+func (l *customLogger) setTrigger(t trigger) {
+	if t.f == nil {
+		l.removeTrigger(t.name)
+		return
+	}
+
+	l.registry.update(func(s *triggerState) {
+		for i, old := range s.triggers {
+			if old.name == t.name {
+				s.triggers[i] = t
+				return
+			}
+		}
+		s.triggers = append(s.triggers, t)
+	})
+}
+
+func (l *customLogger) removeTrigger(name string) {
+	l.registry.update(func(s *triggerState) {
+		triggers := s.triggers[:0]
+		for _, t := range s.triggers {
+			if t.name != name {
+				triggers = append(triggers, t)
+			}
+		}
+		s.triggers = triggers
+	})
+}
+
+// AddAlwaysTrigger adds a trigger called for every query, a trigger with the same name is replaced.
+func (l *customLogger) AddAlwaysTrigger(name string, f func(g GormInfos)) CInterface {
+	l.setTrigger(alwaysTrigger(name, f))
+	return l
+}
+
+// AddSlowTrigger adds a trigger called when the query took more than the duration, a trigger with the same name is replaced.
+func (l *customLogger) AddSlowTrigger(name string, f func(g GormInfos), duration time.Duration) CInterface {
+	l.setTrigger(slowTrigger(name, f, duration))
+	return l
+}
+
+// AddErrorTrigger adds a trigger called when the query fails, see ConsiderNotFound. A trigger with the same name is replaced.
+func (l *customLogger) AddErrorTrigger(name string, f func(g GormInfos)) CInterface {
+	l.setTrigger(errorTrigger(name, f))
+	return l
+}
+
+// RemoveTrigger removes the trigger added with name.
+func (l *customLogger) RemoveTrigger(name string) CInterface {
+	l.removeTrigger(name)
+	return l
+}
+
+// ClearTriggers removes all the triggers, including the ones set with AlwaysTrigger, SlowTrigger...
+func (l *customLogger) ClearTriggers() CInterface {
+	l.registry.update(func(s *triggerState) {
+		s.triggers = nil
+	})
+	return l
+}
+
+// SessionTriggers returns a copy of the logger with its own triggers, starting from the current ones.
+// The triggers changed on the copy don't affect l and the ones changed on l don't affect the copy,
+// while the loggers derived with LogMode share the triggers of their parent:
+//
+//	session := logger.SessionTriggers().AddAlwaysTrigger("import", importMetrics)
+//	db.Session(&gorm.Session{Logger: session})
+func (l *customLogger) SessionTriggers() CInterface {
+	session := *l
+	session.registry = l.registry.fork()
+	return &session
+}
+
+// runTriggers fires the triggers matching e in the order they were added.
+// During maintenance the alerting triggers are skipped and counted.
+func (l customLogger) runTriggers(ctx context.Context, elapsed time.Duration, g GormInfos) {
+	state := l.execution()
+	if len(state.triggers) == 0 {
+		return
+	}
+
+	e := triggerEvent{ctx: ctx, elapsed: elapsed, infos: g, considerNotFound: state.considerRecordNotFoundError}
+	inMaintenance := l.maintenance.active(time.Now())
+	for _, t := range state.triggers {
+		if t.match != nil && !t.match(e) {
+			continue
+		}
+		if t.alerting && inMaintenance {
+			l.maintenance.suppress()
+			continue
+		}
+		if t.line != nil {
+			t.line(l, e.infos)
+		}
+		l.fire(t.f, e.infos)
+	}
+}
+
+// fire calls the trigger f with g, on the worker pool when there is one.
+func (l customLogger) fire(f func(g GormInfos), g GormInfos) {
+	if l.pool == nil {
+		l.call(f, g)
+		return
+	}
+	l.pool.submit(func() { l.call(f, g) })
+}
+
+// ErrTriggerTimeout is given to OnTriggerError when a trigger exceeds the TriggerTimeout.
+var ErrTriggerTimeout = errors.New("cgLogger: trigger timed out")
+
+// TriggerPanic is the error given to OnTriggerError when a trigger panics.
+type TriggerPanic struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (p TriggerPanic) Error() string {
+	return fmt.Sprintf("cgLogger: trigger panicked: %v", p.Value)
+}
+
+// OnTriggerError receives the panics (as a TriggerPanic) and timeouts of the triggers with the infos of the query.
+// The panics are always recovered, without it they are logged at the Error level.
+func (l *customLogger) OnTriggerError(f func(g GormInfos, err error)) CInterface {
+	l.registry.update(func(s *triggerState) {
+		s.triggerError = f
+	})
+	return l
+}
+
+// call runs the trigger recovering its panic, with a trigger timeout it waits at most the timeout.
+func (l customLogger) call(f func(g GormInfos), g GormInfos) {
+	state := l.execution()
+	if state.triggerTimeout <= 0 {
+		l.run(f, g)
+		return
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.run(f, g)
+	}()
+
+	timer := time.NewTimer(state.triggerTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+		err := fmt.Errorf("%w: %v", ErrTriggerTimeout, state.triggerTimeout)
+		if state.triggerError != nil {
+			state.triggerError(g, err)
+			return
+		}
+		l.printf(context.Background(), lg.Warn, LevelWarn, g.Location, "%v", []interface{}{err, "sql", g.Sql})
+	}
+}
+
+// run calls f recovering its panic.
+func (l customLogger) run(f func(g GormInfos), g GormInfos) {
+	defer func() {
+		if r := recover(); r != nil {
+			l.triggerFailed(g, TriggerPanic{Value: r, Stack: debug.Stack()})
+		}
+	}()
+	f(g)
+}
+
+// triggerFailed hands err to OnTriggerError, or logs it.
+func (l customLogger) triggerFailed(g GormInfos, err error) {
+	if onError := l.execution().triggerError; onError != nil {
+		onError(g, err)
+		return
+	}
+	l.printf(context.Background(), lg.Error, LevelError, g.Location, "%v", []interface{}{err, "sql", g.Sql})
+}
+
+// TriggerTimeout sets the max time the query waits for each trigger call. When it is exceeded a warning is logged
+// (or ErrTriggerTimeout is given to OnTriggerError) and the query goes on, the trigger keeps running in background.
+func (l *customLogger) TriggerTimeout(d time.Duration) CInterface {
+	l.registry.update(func(s *triggerState) {
+		s.triggerTimeout = d
+	})
+	return l
+}
@@ -0,0 +1,135 @@
+package cgLogger
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"time"
+)
+
+// WebhookConfig configures a WebhookSink, only URL is required.
+type WebhookConfig struct {
+	URL string
+	// Headers are added to every request, ex: Authorization.
+	Headers map[string]string
+	// Timeout of each attempt, defaults to 5s.
+	Timeout time.Duration
+	// Retries after the first attempt for network errors, 429 and 5xx, defaults to 3. -1 disables them.
+	Retries int
+	// Backoff before the first retry, doubled for each of the next ones. Defaults to 500ms.
+	Backoff time.Duration
+	// QueueSize is the max of events waiting to be sent, the new ones are dropped when full. Defaults to 1000.
+	QueueSize int
+
+	// Client defaults to http.DefaultClient, Timeout is applied per request.
+	Client *http.Client
+	// OnError receives the events that failed after the retries, by default they are ignored.
+	OnError func(error)
+}
+
+// WebhookSink POSTs each GormInfos as JSON (see GormInfos.MarshalJSON) to a URL from a queue,
+// retrying with an exponential backoff.
+//
+//	sink := cgLogger.NewWebhookSink(cgLogger.WebhookConfig{URL: "https://alerts.internal/db", Headers: map[string]string{"Authorization": token}})
+//	defer sink.Close()
+//	logger.SlowTrigger(sink.Trigger)
+type WebhookSink struct {
+	config  WebhookConfig
+	batcher *batcher
+}
+
+// NewWebhookSink starts the goroutine sending the events, Close stops it.
+func NewWebhookSink(config WebhookConfig) *WebhookSink {
+	if config.Timeout <= 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.Retries == 0 {
+		config.Retries = 3
+	}
+	if config.Backoff <= 0 {
+		config.Backoff = 500 * time.Millisecond
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	s := &WebhookSink{config: config}
+	s.batcher = newBatcher(config.QueueSize, 1, time.Second, s.send)
+	return s
+}
+
+// Trigger queues the event, it is meant to be passed to AlwaysTrigger, SlowTrigger or ErrorTrigger.
+func (s *WebhookSink) Trigger(g GormInfos) {
+	s.batcher.add(g)
+}
+
+// Dropped returns how many events were dropped because the queue was full.
+func (s *WebhookSink) Dropped() int64 {
+	return s.batcher.dropCount()
+}
+
+// Flush sends the queued events and waits for them.
+func (s *WebhookSink) Flush() {
+	s.batcher.sync()
+}
+
+// Close sends the queued events and stops the sink.
+func (s *WebhookSink) Close() error {
+	s.batcher.close()
+	return nil
+}
+
+func (s *WebhookSink) send(batch []interface{}) {
+	for _, v := range batch {
+		body, _ := v.(GormInfos).MarshalJSON()
+
+		backoff := s.config.Backoff
+		for attempt := 0; ; attempt++ {
+			retry, err := s.post(body)
+			if err == nil {
+				break
+			}
+			if !retry || attempt >= s.config.Retries {
+				s.fail(err)
+				break
+			}
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+}
+
+// post sends one attempt, retry reports if the error is worth another one.
+func (s *WebhookSink) post(body []byte) (retry bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.config.Client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, responseError("webhook", resp)
+	}
+	return false, nil
+}
+
+func (s *WebhookSink) fail(err error) {
+	if s.config.OnError != nil {
+		s.config.OnError(err)
+	}
+}
@@ -0,0 +1,44 @@
+package cgLogger
+
+import "fmt"
+
+// ZapSugaredLogger is implemented by *zap.SugaredLogger, use Sugar() to adapt a *zap.Logger.
+// It is declared here so the package doesn't depend on zap.
+type ZapSugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// ZapWriter writes to zap at the level of each line, with typed fields:
+// caller, duration (time.Duration), rows (int64), sql, error and the Info/Warn/Error fields.
+//
+//	logger := cgLogger.New(cgLogger.NewZapWriter(zapLogger.Sugar()), config)
+type ZapWriter struct {
+	Logger ZapSugaredLogger
+}
+
+// NewZapWriter returns a Writer logging to l.
+func NewZapWriter(l ZapSugaredLogger) ZapWriter {
+	return ZapWriter{Logger: l}
+}
+
+// Printf logs the lines that don't come as entries at the Info level.
+func (w ZapWriter) Printf(format string, data ...interface{}) {
+	w.Logger.Infow(fmt.Sprintf(format, data...))
+}
+
+// WriteEntry logs e at the matching zap level.
+func (w ZapWriter) WriteEntry(e Entry) {
+	log := w.Logger.Infow
+	switch e.Level {
+	case LevelDebug:
+		log = w.Logger.Debugw
+	case LevelWarn:
+		log = w.Logger.Warnw
+	case LevelError:
+		log = w.Logger.Errorw
+	}
+	log(e.message(), e.keyValues()...)
+}